@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -9,6 +11,8 @@ import (
 	"github.com/unwelcome/iqjtest/internal/config"
 	"github.com/unwelcome/iqjtest/internal/dependency_injection"
 	"github.com/unwelcome/iqjtest/internal/routes"
+	"github.com/unwelcome/iqjtest/pkg/policy"
+	"github.com/unwelcome/iqjtest/pkg/utils"
 )
 
 // @title           IQJ Test Task
@@ -34,11 +38,20 @@ func main() {
 	defer postgres.Close()
 	defer redis.Close()
 
+	// Загрузка ключей подписи jwt токенов
+	keyring := utils.InitTokenKeyring(cfg.JWTKeysDir, cfg.JWTActiveKeyID, cfg.JWTSecret, logger)
+
+	// Загрузка списка утекших паролей
+	breachedPasswords := policy.InitBreachedPasswords(cfg.BreachedPasswordsFile, logger)
+
 	// Инициализация fiber
 	app := fiber.New()
 
 	// Создание контейнера с dependency injection
-	container := dependency_injection.NewContainer(postgres, redis, minio, cfg, logger)
+	container := dependency_injection.NewContainer(postgres, redis, minio, keyring, breachedPasswords, cfg, logger)
+
+	// Запуск фонового удаления аккаунтов, срок отмены удаления которых истек
+	go container.AccountPurgeService.Run(context.Background())
 
 	// Инициализация роутов
 	routes.SetupRoutes(app, container)
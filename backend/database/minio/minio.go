@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 	"github.com/rs/zerolog"
 )
 
@@ -17,10 +18,14 @@ type ConnectConfig struct {
 }
 
 type Bucket struct {
-	Name   string
-	IsOpen bool
+	Name           string
+	IsOpen         bool
+	ExpirationDays int
 }
 
+// Регион minio по умолчанию. Указывается для клиента подписи ссылок, чтобы он не запрашивал регион бакета по сети
+const defaultRegion = "us-east-1"
+
 func InitMinio(cfg *ConnectConfig, l zerolog.Logger, buckets []*Bucket) *minio.Client {
 	minioClient, err := ConnectMinio(cfg)
 	if err != nil {
@@ -41,6 +46,14 @@ func InitMinio(cfg *ConnectConfig, l zerolog.Logger, buckets []*Bucket) *minio.C
 				l.Fatal().Str("bucketName", bucket.Name).Err(err).Msg("Failed to set bucket policy")
 			}
 		}
+
+		// Выставляем срок хранения объектов в бакете
+		if bucket.ExpirationDays > 0 {
+			err = SetBucketExpiration(context.Background(), minioClient, bucket.Name, bucket.ExpirationDays)
+			if err != nil {
+				l.Fatal().Str("bucketName", bucket.Name).Err(err).Msg("Failed to set bucket lifecycle")
+			}
+		}
 	}
 
 	l.Trace().Msg("Successfully connected to MinIO")
@@ -66,6 +79,22 @@ func ConnectMinio(cfg *ConnectConfig) (*minio.Client, error) {
 	return minioClient, nil
 }
 
+// Клиент для подписи временных ссылок. Адрес minio входит в подпись, поэтому ссылки подписываются публичным адресом,
+// по которому к minio обращается пользователь. Подпись выполняется локально, подключение к minio не требуется
+
+func InitPresignClient(cfg *ConnectConfig, l zerolog.Logger) *minio.Client {
+	minioClient, err := minio.New(cfg.PublicEndpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.Username, cfg.Password, ""),
+		Secure: cfg.UseSSL,
+		Region: defaultRegion,
+	})
+	if err != nil {
+		l.Fatal().Err(err).Msg("Failed to create MinIO presign client")
+	}
+
+	return minioClient
+}
+
 func CreateBucketIfNotExists(ctx context.Context, minioClient *minio.Client, bucketName string) error {
 	// Создаем bucket
 	err := minioClient.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{})
@@ -96,3 +125,15 @@ func SetOpenBucketPolicy(ctx context.Context, minioClient *minio.Client, bucketN
 	}`, bucketName)
 	return minioClient.SetBucketPolicy(ctx, bucketName, policy)
 }
+
+func SetBucketExpiration(ctx context.Context, minioClient *minio.Client, bucketName string, days int) error {
+	config := lifecycle.NewConfiguration()
+	config.Rules = []lifecycle.Rule{
+		{
+			ID:         "expire-objects",
+			Status:     "Enabled",
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+		},
+	}
+	return minioClient.SetBucketLifecycle(ctx, bucketName, config)
+}
@@ -3,7 +3,22 @@
 CREATE TABLE "users" (
     "id" SERIAL PRIMARY KEY,
     "login" varchar(255) NOT NULL UNIQUE,
+    "email" varchar(255),
+    "email_verified" bool NOT NULL DEFAULT false,
+    "totp_secret" varchar(64),
+    "totp_enabled" bool NOT NULL DEFAULT false,
+    "totp_last_step" bigint,
     "password_hash" varchar(255) NOT NULL,
+    "role" varchar(32) NOT NULL DEFAULT 'user',
+    "display_name" varchar(64) NOT NULL DEFAULT '',
+    "bio" text NOT NULL DEFAULT '',
+    "location" varchar(100) NOT NULL DEFAULT '',
+    "avatar_url" text,
+    "avatar_filename" text,
+    "deletion_requested_at" timestamp,
+    "deletion_requested_by" integer,
+    "purge_after" timestamp,
+    "purge_started_at" timestamp,
     "created_at" timestamp NOT NULL DEFAULT NOW()
 );
 
@@ -27,9 +42,97 @@ CREATE TABLE "cat_photos" (
     "is_primary" bool DEFAULT false
 );
 
+CREATE TABLE "user_recovery_codes" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "code_hash" varchar(64) NOT NULL,
+    "used_at" timestamp,
+    "created_at" timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE "api_keys" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "key_prefix" varchar(32) NOT NULL,
+    "key_hash" varchar(64) NOT NULL UNIQUE,
+    "scopes" text[] NOT NULL,
+    "expires_at" timestamp,
+    "last_used_at" timestamp,
+    "created_at" timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE "user_identities" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "provider" varchar(64) NOT NULL,
+    "subject" varchar(255) NOT NULL,
+    "email" varchar(255),
+    "created_at" timestamp NOT NULL DEFAULT NOW(),
+    UNIQUE ("provider", "subject")
+);
+
+CREATE TABLE "invitations" (
+    "id" SERIAL PRIMARY KEY,
+    "created_by" integer NOT NULL,
+    "code_prefix" varchar(32) NOT NULL,
+    "code_hash" varchar(64) NOT NULL UNIQUE,
+    "role" varchar(32),
+    "max_uses" integer NOT NULL DEFAULT 1,
+    "uses" integer NOT NULL DEFAULT 0,
+    "expires_at" timestamp,
+    "created_at" timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Журнал аудита: только добавление записей, без внешних ключей, чтобы события переживали удаление пользователей и котиков
+CREATE TABLE "audit_events" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "actor_id" integer,
+    "impersonator_id" integer,
+    "action" varchar(64) NOT NULL,
+    "target_type" varchar(32) NOT NULL DEFAULT '',
+    "target_id" varchar(255) NOT NULL DEFAULT '',
+    "outcome" varchar(16) NOT NULL,
+    "ip" varchar(64) NOT NULL DEFAULT '',
+    "user_agent" text NOT NULL DEFAULT '',
+    "details" text NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT NOW()
+);
+
 CREATE INDEX idx_users_login ON users(login);
+-- Подтвержденный email уникален без учета регистра, сервис сохраняет его в нижнем регистре.
+-- Неподтвержденный адрес может быть указан у нескольких пользователей, владельца определяет подтверждение
+CREATE UNIQUE INDEX users_email_lower_key ON users(lower(email)) WHERE email_verified;
+CREATE INDEX idx_users_created_at ON users(created_at, id);
+CREATE INDEX idx_cats_created_by ON cats(created_by);
+CREATE INDEX idx_cats_created_at ON cats(created_at, id);
+CREATE INDEX idx_cats_name ON cats(name, id);
+CREATE INDEX idx_cats_age ON cats(age, id);
 CREATE INDEX idx_cat_photos_cat_id ON cat_photos(cat_id);
 CREATE INDEX idx_cat_photos_primary ON cat_photos(cat_id, is_primary);
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+CREATE INDEX idx_users_purge_after ON users(purge_after) WHERE purge_after IS NOT NULL;
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+CREATE INDEX idx_invitations_created_by ON invitations(created_by);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_impersonator_id ON audit_events(impersonator_id);
+CREATE INDEX idx_audit_events_action ON audit_events(action);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
 
 ALTER TABLE "cats" ADD CONSTRAINT "cats_to_users" FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE CASCADE;
 ALTER TABLE "cat_photos" ADD CONSTRAINT "cat_photos_to_cats" FOREIGN KEY ("cat_id") REFERENCES "cats" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_recovery_codes" ADD CONSTRAINT "user_recovery_codes_to_users" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "api_keys" ADD CONSTRAINT "api_keys_to_users" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_identities" ADD CONSTRAINT "user_identities_to_users" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "invitations" ADD CONSTRAINT "invitations_to_users" FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_no_update_delete" BEFORE UPDATE OR DELETE ON "audit_events"
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -5,8 +5,13 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/unwelcome/iqjtest/database/minio"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/pkg/hasher"
+	"github.com/unwelcome/iqjtest/pkg/utils"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -35,11 +40,88 @@ type Config struct {
 	S3UseSSL   bool
 	S3Buckets  map[string]*miniodb.Bucket
 
-	BCryptCost           int
+	PasswordHashing struct {
+		Argon2Memory      uint32
+		Argon2Iterations  uint32
+		Argon2Parallelism uint8
+		BCryptCost        int
+	}
+
 	JWTSecret            string
+	JWTKeysDir           string
+	JWTActiveKeyID       string
 	AccessTokenLifetime  time.Duration
 	RefreshTokenLifetime time.Duration
 
+	ImpersonationTokenLifetime time.Duration
+
+	LoginProtection *entities.LoginProtectionPolicy
+
+	PasswordPolicy        *entities.PasswordPolicy
+	BreachedPasswordsFile string
+
+	Registration struct {
+		Mode                  string
+		InvitationCreatorRole string
+		InvitationLifetime    time.Duration
+	}
+
+	TwoFactor struct {
+		Issuer            string
+		ChallengeLifetime time.Duration
+	}
+
+	OIDC struct {
+		Provider      string
+		IssuerURL     string
+		ClientID      string
+		ClientSecret  string
+		RedirectURL   string
+		Scopes        []string
+		StateLifetime time.Duration
+	}
+
+	PasswordReset struct {
+		TokenLifetime time.Duration
+		URL           string
+	}
+
+	EmailVerification struct {
+		TokenLifetime time.Duration
+		URL           string
+		Required      bool
+	}
+
+	Mail struct {
+		Transport    string
+		SMTPHost     string
+		SMTPPort     string
+		SMTPUser     string
+		SMTPPassword string
+		From         string
+		LogFile      string
+	}
+
+	AccountDeletion struct {
+		GracePeriod    time.Duration
+		PurgeInterval  time.Duration
+		PurgeBatchSize int
+		PurgeTimeout   time.Duration
+	}
+
+	DataExport struct {
+		Lifetime     time.Duration
+		LinkLifetime time.Duration
+		BuildTimeout time.Duration
+	}
+
+	AuthCookie struct {
+		Enabled  bool
+		Domain   string
+		Secure   bool
+		SameSite string
+	}
+
 	Timeouts struct {
 		Middleware  time.Duration
 		Request     time.Duration
@@ -90,17 +172,114 @@ func LoadConfig(l zerolog.Logger) *Config {
 		cfg.S3Host = getEnv("MINIO_HOST", "minio")
 	}
 
-	// Устанавливаем стойкость шифрования пароля
-	cfg.BCryptCost = 10
+	// Хеширование паролей: новые пароли хешируются argon2id, старые хеши bcrypt проверяются и
+	// пересоздаются при входе. При изменении параметров argon2id хеши тоже пересоздаются при входе
+	cfg.PasswordHashing.Argon2Memory = uint32(getEnvInt("ARGON2_MEMORY_KIB", 64*1024))
+	cfg.PasswordHashing.Argon2Iterations = uint32(getEnvInt("ARGON2_ITERATIONS", 3))
+	cfg.PasswordHashing.Argon2Parallelism = uint8(getEnvInt("ARGON2_PARALLELISM", 2))
+	cfg.PasswordHashing.BCryptCost = 10
 
-	// Инициализируем jwt секрет
+	// Инициализируем jwt секрет (используется, если не указан каталог с ключами RS256/EdDSA)
 	cfg.JWTSecret = getEnv("JWT_SECRET", "ultra-secret-key")
+	cfg.JWTKeysDir = getEnv("JWT_KEYS_DIR", "")
+	cfg.JWTActiveKeyID = getEnv("JWT_ACTIVE_KEY_ID", "")
 	cfg.AccessTokenLifetime = 5 * time.Minute
 	cfg.RefreshTokenLifetime = 30 * 24 * time.Hour
 
+	// Токен имперсонации живет не дольше access токена: его ID хранится в сете access токенов пользователя,
+	// TTL которого обновляется при выдаче каждого access токена
+	cfg.ImpersonationTokenLifetime = min(5*time.Minute, cfg.AccessTokenLifetime)
+
+	// Защита от перебора паролей: лимиты неудачных попыток входа и время блокировки
+	cfg.LoginProtection = &entities.LoginProtectionPolicy{
+		MaxAttemptsPerLogin: getEnvInt("LOGIN_MAX_ATTEMPTS_PER_LOGIN", 5),
+		MaxAttemptsPerIP:    getEnvInt("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+		AttemptsWindow:      time.Hour,
+		LockoutDuration:     30 * time.Second,
+		MaxLockoutDuration:  15 * time.Minute,
+	}
+
+	// Политика паролей. Проверка по списку утекших паролей включается указанием файла BREACHED_PASSWORDS_FILE
+	cfg.PasswordPolicy = &entities.PasswordPolicy{
+		MinLength:        getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        1024,
+		RequireLowercase: getEnvBool("PASSWORD_REQUIRE_LOWERCASE", false),
+		RequireUppercase: getEnvBool("PASSWORD_REQUIRE_UPPERCASE", false),
+		RequireDigit:     getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+		RequireSymbol:    getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		ForbidLogin:      true,
+		CheckBreached:    true,
+	}
+	cfg.BreachedPasswordsFile = getEnv("BREACHED_PASSWORDS_FILE", "")
+
+	// Регистрация: open - открытая, invite_only - только по коду приглашения, closed - закрыта.
+	// Коды приглашений могут создавать пользователи с ролью не ниже INVITATION_CREATOR_ROLE
+	cfg.Registration.Mode = getEnv("REGISTRATION_MODE", entities.RegistrationModeOpen)
+	if !slices.Contains([]string{entities.RegistrationModeOpen, entities.RegistrationModeInviteOnly, entities.RegistrationModeClosed}, cfg.Registration.Mode) {
+		l.Fatal().Str("REGISTRATION_MODE", cfg.Registration.Mode).Msg("invalid registration mode")
+	}
+	cfg.Registration.InvitationCreatorRole = getEnv("INVITATION_CREATOR_ROLE", entities.RoleUser)
+	if !entities.IsValidRole(cfg.Registration.InvitationCreatorRole) {
+		l.Fatal().Str("INVITATION_CREATOR_ROLE", cfg.Registration.InvitationCreatorRole).Msg("invalid invitation creator role")
+	}
+	cfg.Registration.InvitationLifetime = 7 * 24 * time.Hour
+
+	// 2FA: название сервиса в приложении-аутентификаторе и время жизни challenge токена второго шага входа
+	cfg.TwoFactor.Issuer = getEnv("TOTP_ISSUER", "IQJ Test Task")
+	cfg.TwoFactor.ChallengeLifetime = 5 * time.Minute
+
+	// Вход через OIDC провайдера (включен, если указаны OIDC_ISSUER_URL и OIDC_CLIENT_ID).
+	// OIDC_PROVIDER - название провайдера, под которым сохраняются привязанные аккаунты
+	cfg.OIDC.Provider = getEnv("OIDC_PROVIDER", "oidc")
+	cfg.OIDC.IssuerURL = getEnv("OIDC_ISSUER_URL", "")
+	cfg.OIDC.ClientID = getEnv("OIDC_CLIENT_ID", "")
+	cfg.OIDC.ClientSecret = getEnv("OIDC_CLIENT_SECRET", "")
+	cfg.OIDC.RedirectURL = getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/oidc/callback")
+	cfg.OIDC.Scopes = strings.Fields(getEnv("OIDC_SCOPES", "openid email profile"))
+	cfg.OIDC.StateLifetime = 10 * time.Minute
+
+	// Сброс пароля: время жизни токена и адрес страницы, на которую ведет ссылка из письма
+	cfg.PasswordReset.TokenLifetime = 30 * time.Minute
+	cfg.PasswordReset.URL = getEnv("PASSWORD_RESET_URL", "http://localhost:8080/reset-password")
+
+	// Подтверждение email: время жизни токена, адрес страницы подтверждения и запрет создания котов без подтвержденного email
+	cfg.EmailVerification.TokenLifetime = 24 * time.Hour
+	cfg.EmailVerification.URL = getEnv("EMAIL_VERIFICATION_URL", "http://localhost:8080/verify-email")
+	cfg.EmailVerification.Required = getEnvBool("REQUIRE_VERIFIED_EMAIL", false)
+
+	// Отправка писем: log - письма пишутся в лог (и файл MAIL_LOG_FILE), smtp - отправляются через SMTP сервер
+	cfg.Mail.Transport = getEnv("MAIL_TRANSPORT", "log")
+	cfg.Mail.SMTPHost = getEnv("SMTP_HOST", "localhost")
+	cfg.Mail.SMTPPort = getEnv("SMTP_PORT", "1025")
+	cfg.Mail.SMTPUser = getEnv("SMTP_USER", "")
+	cfg.Mail.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	cfg.Mail.From = getEnv("MAIL_FROM", "noreply@localhost")
+	cfg.Mail.LogFile = getEnv("MAIL_LOG_FILE", "")
+
+	// Удаление аккаунта: срок, в течение которого удаление можно отменить входом в аккаунт, и параметры фоновой очистки.
+	// PurgeTimeout - время очистки одного пользователя, прерванная очистка повторяется после него
+	cfg.AccountDeletion.GracePeriod = time.Duration(getEnvInt("ACCOUNT_DELETION_GRACE_DAYS", 30)) * 24 * time.Hour
+	cfg.AccountDeletion.PurgeInterval = time.Hour
+	cfg.AccountDeletion.PurgeBatchSize = 100
+	cfg.AccountDeletion.PurgeTimeout = 10 * time.Minute
+
+	// Выгрузка данных пользователя: время хранения архива, время жизни ссылки на скачивание и ограничение времени сборки архива.
+	// Архивы хранятся в закрытом бакете и удаляются minio через сутки
+	cfg.DataExport.Lifetime = 24 * time.Hour
+	cfg.DataExport.LinkLifetime = 15 * time.Minute
+	cfg.DataExport.BuildTimeout = 10 * time.Minute
+
+	// Способ передачи токенов: header - в теле ответа и заголовке Authorization, cookie - в HttpOnly cookie
+	cfg.AuthCookie.Enabled = getEnv("AUTH_TRANSPORT", "header") == "cookie"
+	cfg.AuthCookie.Domain = getEnv("AUTH_COOKIE_DOMAIN", "")
+	cfg.AuthCookie.Secure = getEnvBool("AUTH_COOKIE_SECURE", true)
+	cfg.AuthCookie.SameSite = getEnv("AUTH_COOKIE_SAMESITE", "Strict")
+
 	// Декларируем S3 бакеты
 	cfg.S3Buckets = map[string]*miniodb.Bucket{
-		"catPhotoBucket": &miniodb.Bucket{Name: "cat-photo-bucket", IsOpen: true},
+		"catPhotoBucket":   &miniodb.Bucket{Name: "cat-photo-bucket", IsOpen: true},
+		"dataExportBucket": &miniodb.Bucket{Name: "data-export-bucket", IsOpen: false, ExpirationDays: 1},
+		"avatarBucket":     &miniodb.Bucket{Name: "avatar-bucket", IsOpen: true},
 	}
 
 	// Устанавливаем время выполнения запросов
@@ -142,6 +321,32 @@ func (c *Config) S3ConnConfig() *miniodb.ConnectConfig {
 	}
 }
 
+func (c *Config) TokenCookieOptions() *utils.TokenCookieOptions {
+	if !c.AuthCookie.Enabled {
+		return nil
+	}
+	return &utils.TokenCookieOptions{
+		Domain:               c.AuthCookie.Domain,
+		Secure:               c.AuthCookie.Secure,
+		SameSite:             c.AuthCookie.SameSite,
+		AccessTokenLifetime:  c.AccessTokenLifetime,
+		RefreshTokenLifetime: c.RefreshTokenLifetime,
+	}
+}
+
+func (c *Config) PasswordHasher() hasher.PasswordHasher {
+	argon2id := hasher.NewArgon2id(&hasher.Argon2idParams{
+		Memory:      c.PasswordHashing.Argon2Memory,
+		Iterations:  c.PasswordHashing.Argon2Iterations,
+		Parallelism: c.PasswordHashing.Argon2Parallelism,
+	})
+	return hasher.NewPasswordHasher(argon2id, hasher.NewBCrypt(c.PasswordHashing.BCryptCost))
+}
+
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDC.IssuerURL != "" && c.OIDC.ClientID != ""
+}
+
 func (c *Config) GetS3Buckets() []*miniodb.Bucket {
 	var buckets []*miniodb.Bucket
 	for _, bucket := range c.S3Buckets {
@@ -7,22 +7,47 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/database/minio"
 	"github.com/unwelcome/iqjtest/internal/config"
+	"github.com/unwelcome/iqjtest/internal/entities"
 	"github.com/unwelcome/iqjtest/internal/handlers"
 	"github.com/unwelcome/iqjtest/internal/middlewares"
 	"github.com/unwelcome/iqjtest/internal/repositories"
 	"github.com/unwelcome/iqjtest/internal/services"
+	"github.com/unwelcome/iqjtest/pkg/mailer"
+	"github.com/unwelcome/iqjtest/pkg/oidc"
+	"github.com/unwelcome/iqjtest/pkg/policy"
+	"github.com/unwelcome/iqjtest/pkg/utils"
 )
 
 type Container struct {
 	// Middleware
-	LoggingMiddleware      func(c *fiber.Ctx) error
-	AuthMiddleware         func(c *fiber.Ctx) error
-	CatOwnershipMiddleware func(c *fiber.Ctx) error
+	LoggingMiddleware        func(c *fiber.Ctx) error
+	AuthMiddleware           func(c *fiber.Ctx) error
+	CSRFMiddleware           func(c *fiber.Ctx) error
+	CatOwnershipMiddleware   func(c *fiber.Ctx) error
+	EmailVerifiedMiddleware  func(c *fiber.Ctx) error
+	RateLimitMiddleware      func(policy *entities.RateLimitPolicy) fiber.Handler
+	RequireRoleMiddleware    func(requiredRole string) fiber.Handler
+	RequireScopeMiddleware   func(requiredScope string) fiber.Handler
+	RequireSessionMiddleware func(c *fiber.Ctx) error
+
+	ForbidImpersonationMiddleware func(c *fiber.Ctx) error
 
 	// Health
 	HealthHandler handlers.HealthHandler
 
+	// Rate limit
+	rateLimitRepository repositories.RateLimitRepository
+
+	// Mail
+	mailer mailer.Mailer
+
+	// Audit
+	auditRepository repositories.AuditRepository
+	auditLogger     services.AuditLogger
+	AuditHandler    handlers.AuditHandler
+
 	// Auth
 	authRepository repositories.AuthRepository
 	authService    services.AuthService
@@ -33,6 +58,43 @@ type Container struct {
 	userService    services.UserService
 	UserHandler    handlers.UserHandler
 
+	// Profile
+	profileRepository repositories.ProfileRepository
+	profileService    services.ProfileService
+	ProfileHandler    handlers.ProfileHandler
+
+	// Email
+	emailService services.EmailService
+	EmailHandler handlers.EmailHandler
+
+	// API keys
+	apiKeyRepository repositories.APIKeyRepository
+	apiKeyService    services.APIKeyService
+	APIKeyHandler    handlers.APIKeyHandler
+
+	// Invitations
+	invitationRepository repositories.InvitationRepository
+	invitationService    services.InvitationService
+	InvitationHandler    handlers.InvitationHandler
+
+	// OIDC
+	userIdentityRepository repositories.UserIdentityRepository
+	oidcService            services.OIDCService
+	OIDCHandler            handlers.OIDCHandler
+
+	// Two-factor
+	twoFactorRepository repositories.TwoFactorRepository
+	twoFactorService    services.TwoFactorService
+	TwoFactorHandler    handlers.TwoFactorHandler
+
+	// Data export
+	dataExportRepository repositories.DataExportRepository
+	dataExportService    services.DataExportService
+	DataExportHandler    handlers.DataExportHandler
+
+	// Account purge
+	AccountPurgeService services.AccountPurgeService
+
 	// Cat
 	catRepository repositories.CatRepository
 	catService    services.CatService
@@ -44,15 +106,18 @@ type Container struct {
 	CatPhotoHandler    handlers.CatPhotoHandler
 }
 
-func NewContainer(postgres *sql.DB, redis *redis.Client, minio *minio.Client, cfg *config.Config, logger zerolog.Logger) *Container {
+func NewContainer(postgres *sql.DB, redis *redis.Client, minio *minio.Client, keyring *utils.TokenKeyring, breachedPasswords policy.BreachedPasswords, cfg *config.Config, logger zerolog.Logger) *Container {
 	// Создание контейнера
 	container := &Container{}
 
 	// Инициализация репозиториев
-	container.InitRepositories(postgres, redis, minio, cfg)
+	container.InitRepositories(postgres, redis, minio, logger, cfg)
+
+	// Инициализация отправки писем
+	container.InitMailer(logger, cfg)
 
 	// Инициализация сервисов
-	container.InitServices(cfg)
+	container.InitServices(keyring, breachedPasswords, logger, cfg)
 
 	// Инициализация хендлеров
 	container.InitHandlers(cfg)
@@ -65,28 +130,77 @@ func NewContainer(postgres *sql.DB, redis *redis.Client, minio *minio.Client, cf
 
 func (c *Container) InitMiddlewares(logger zerolog.Logger, cfg *config.Config) {
 	c.LoggingMiddleware = middlewares.LoggingRequest(logger)
-	c.AuthMiddleware = middlewares.AuthMiddleware(cfg.JWTSecret)
+	c.AuthMiddleware = middlewares.AuthMiddleware(c.authService, c.apiKeyService, cfg.TokenCookieOptions(), cfg.Timeouts.Middleware)
+	c.CSRFMiddleware = middlewares.CSRFMiddleware()
 	c.CatOwnershipMiddleware = middlewares.CatOwnershipMiddleware(c.catService, cfg.Timeouts.Middleware)
+	c.EmailVerifiedMiddleware = middlewares.EmailVerifiedMiddleware(c.emailService, cfg.EmailVerification.Required, cfg.Timeouts.Middleware)
+	c.RateLimitMiddleware = middlewares.RateLimitMiddleware(c.rateLimitRepository, cfg.Timeouts.Middleware)
+	c.RequireRoleMiddleware = middlewares.RequireRole
+	c.RequireScopeMiddleware = middlewares.RequireScope
+	c.RequireSessionMiddleware = middlewares.RequireSession
+	c.ForbidImpersonationMiddleware = middlewares.ForbidImpersonation
 }
 
-func (c *Container) InitRepositories(postgres *sql.DB, redis *redis.Client, minio *minio.Client, cfg *config.Config) {
+func (c *Container) InitRepositories(postgres *sql.DB, redis *redis.Client, minio *minio.Client, logger zerolog.Logger, cfg *config.Config) {
 	c.userRepository = repositories.NewUserRepository(postgres)
 	c.authRepository = repositories.NewAuthRepository(redis)
+	c.twoFactorRepository = repositories.NewTwoFactorRepository(postgres)
+	c.apiKeyRepository = repositories.NewAPIKeyRepository(postgres)
+	c.invitationRepository = repositories.NewInvitationRepository(postgres)
+	c.userIdentityRepository = repositories.NewUserIdentityRepository(postgres)
+	c.rateLimitRepository = repositories.NewRateLimitRepository(redis)
+	c.auditRepository = repositories.NewAuditRepository(postgres)
 	c.catRepository = repositories.NewCatRepository(postgres)
 	c.catPhotoRepository = repositories.NewCatPhotoRepository(postgres, minio, cfg.S3ConnConfig().PublicEndpoint, cfg.S3Buckets["catPhotoBucket"].Name)
+	c.profileRepository = repositories.NewProfileRepository(postgres, minio, cfg.S3ConnConfig().PublicEndpoint, cfg.S3Buckets["avatarBucket"].Name)
+	c.dataExportRepository = repositories.NewDataExportRepository(redis, minio, miniodb.InitPresignClient(cfg.S3ConnConfig(), logger), cfg.S3Buckets["dataExportBucket"].Name)
+}
+
+func (c *Container) InitMailer(logger zerolog.Logger, cfg *config.Config) {
+	if cfg.Mail.Transport == "smtp" {
+		c.mailer = mailer.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUser, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	} else {
+		c.mailer = mailer.NewLogMailer(logger, cfg.Mail.LogFile)
+	}
 }
 
-func (c *Container) InitServices(cfg *config.Config) {
-	c.userService = services.NewUserService(c.userRepository, cfg.BCryptCost)
-	c.authService = services.NewAuthService(c.userService, c.authRepository, cfg.JWTSecret, cfg.AccessTokenLifetime, cfg.RefreshTokenLifetime)
-	c.catPhotoService = services.NewCatPhotoService(c.catPhotoRepository)
-	c.catService = services.NewCatService(c.catRepository, c.catPhotoService)
+func (c *Container) InitServices(keyring *utils.TokenKeyring, breachedPasswords policy.BreachedPasswords, logger zerolog.Logger, cfg *config.Config) {
+	c.auditLogger = services.NewAuditLogger(c.auditRepository, logger, cfg.Timeouts.Request)
+	c.userService = services.NewUserService(c.userRepository, cfg.PasswordHasher(), policy.NewPasswordValidator(cfg.PasswordPolicy, breachedPasswords))
+	c.profileService = services.NewProfileService(c.profileRepository, c.auditLogger)
+	c.emailService = services.NewEmailService(c.userRepository, c.authRepository, c.mailer, logger, cfg.EmailVerification.TokenLifetime, cfg.EmailVerification.URL)
+	c.apiKeyService = services.NewAPIKeyService(c.apiKeyRepository)
+	c.invitationService = services.NewInvitationService(c.invitationRepository, cfg.Registration.InvitationCreatorRole, cfg.Registration.InvitationLifetime)
+	c.twoFactorService = services.NewTwoFactorService(c.twoFactorRepository, c.userRepository, c.authRepository, cfg.LoginProtection, cfg.TwoFactor.Issuer)
+	c.oidcService = services.NewOIDCService(c.initOIDCProvider(cfg), c.userIdentityRepository, c.userRepository, c.userService, c.authRepository, cfg.Registration.Mode, cfg.OIDC.StateLifetime)
+	c.authService = services.NewAuthService(c.userService, c.emailService, c.twoFactorService, c.oidcService, c.auditLogger, c.authRepository, c.mailer, logger, keyring, cfg.AccessTokenLifetime, cfg.RefreshTokenLifetime, cfg.ImpersonationTokenLifetime, cfg.Registration.Mode, cfg.AccountDeletion.GracePeriod, cfg.LoginProtection, cfg.TwoFactor.ChallengeLifetime, cfg.PasswordReset.TokenLifetime, cfg.PasswordReset.URL)
+	c.catPhotoService = services.NewCatPhotoService(c.catPhotoRepository, c.auditLogger)
+	c.catService = services.NewCatService(c.catRepository, c.catPhotoService, c.auditLogger)
+	c.dataExportService = services.NewDataExportService(c.userRepository, c.catRepository, c.catPhotoRepository, c.dataExportRepository, c.auditLogger, logger, cfg.DataExport.Lifetime, cfg.DataExport.LinkLifetime, cfg.DataExport.BuildTimeout)
+	c.AccountPurgeService = services.NewAccountPurgeService(c.userRepository, c.catRepository, c.catPhotoRepository, c.profileRepository, c.dataExportRepository, c.auditLogger, logger, cfg.AccountDeletion.PurgeInterval, cfg.AccountDeletion.PurgeBatchSize, cfg.AccountDeletion.PurgeTimeout)
 }
 
 func (c *Container) InitHandlers(cfg *config.Config) {
 	c.HealthHandler = handlers.NewHealthHandler()
+	c.AuditHandler = handlers.NewAuditHandler(c.auditLogger, cfg.Timeouts.Request)
 	c.UserHandler = handlers.NewUserHandler(c.userService, cfg.Timeouts.Request)
-	c.AuthHandler = handlers.NewAuthHandler(c.authService, cfg.Timeouts.Request)
+	c.ProfileHandler = handlers.NewProfileHandler(c.profileService, cfg.Timeouts.Request, cfg.Timeouts.FileRequest)
+	c.EmailHandler = handlers.NewEmailHandler(c.emailService, cfg.Timeouts.Request)
+	c.APIKeyHandler = handlers.NewAPIKeyHandler(c.apiKeyService, cfg.Timeouts.Request)
+	c.InvitationHandler = handlers.NewInvitationHandler(c.invitationService, cfg.Timeouts.Request)
+	c.TwoFactorHandler = handlers.NewTwoFactorHandler(c.twoFactorService, cfg.Timeouts.Request)
+	c.AuthHandler = handlers.NewAuthHandler(c.authService, cfg.TokenCookieOptions(), cfg.Timeouts.Request)
+	c.OIDCHandler = handlers.NewOIDCHandler(c.oidcService, c.authService, cfg.TokenCookieOptions(), cfg.OIDC.StateLifetime, cfg.AuthCookie.Secure, cfg.Timeouts.Request)
+	c.DataExportHandler = handlers.NewDataExportHandler(c.dataExportService, cfg.Timeouts.Request)
 	c.CatHandler = handlers.NewCatHandler(c.catService, cfg.Timeouts.Request, cfg.Timeouts.FileRequest)
 	c.CatPhotoHandler = handlers.NewCatPhotoHandler(c.catPhotoService, cfg.Timeouts.Request, cfg.Timeouts.FileRequest)
 }
+
+// Клиент OIDC провайдера (nil - вход через OIDC отключен)
+
+func (c *Container) initOIDCProvider(cfg *config.Config) oidc.Provider {
+	if !cfg.OIDCEnabled() {
+		return nil
+	}
+	return oidc.NewProvider(cfg.OIDC.Provider, cfg.OIDC.IssuerURL, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, cfg.OIDC.RedirectURL, cfg.OIDC.Scopes)
+}
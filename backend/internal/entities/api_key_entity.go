@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+const (
+	ScopeCatsRead    = "cats:read"
+	ScopeCatsWrite   = "cats:write"
+	ScopePhotosWrite = "photos:write"
+)
+
+// Префикс API ключа, по нему ключ легко найти в коде и логах
+const APIKeyPrefix = "iqj_"
+
+var APIKeyScopes = []string{ScopeCatsRead, ScopeCatsWrite, ScopePhotosWrite}
+
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"key_prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  string     `json:"created_at" db:"created_at"`
+}
+
+type APIKeyCreateRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type APIKeyCreateResponse struct {
+	*APIKey
+	Key string `json:"key"`
+}
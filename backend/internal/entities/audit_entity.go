@@ -0,0 +1,86 @@
+package entities
+
+import "time"
+
+// Действия журнала аудита
+const (
+	AuditActionRegister        = "user.register"
+	AuditActionLogin           = "user.login"
+	AuditActionLoginTwoFactor  = "user.login_2fa"
+	AuditActionLoginOIDC       = "user.login_oidc"
+	AuditActionRefresh         = "user.refresh"
+	AuditActionLogout          = "user.logout"
+	AuditActionPasswordChange  = "user.password_change"
+	AuditActionPasswordReset   = "user.password_reset"
+	AuditActionRoleChange      = "user.role_change"
+	AuditActionDeleteUser      = "user.delete"
+	AuditActionRestoreUser     = "user.restore"
+	AuditActionPurgeUser       = "user.purge"
+	AuditActionImpersonate     = "user.impersonate"
+	AuditActionDataExport      = "user.data_export"
+	AuditActionUpdateProfile   = "user.profile_update"
+	AuditActionUpdateAvatar    = "user.avatar_update"
+	AuditActionDeleteAvatar    = "user.avatar_delete"
+	AuditActionCreateCat       = "cat.create"
+	AuditActionDeleteCat       = "cat.delete"
+	AuditActionAddCatPhoto     = "cat_photo.add"
+	AuditActionSetPrimaryPhoto = "cat_photo.set_primary"
+	AuditActionDeleteCatPhoto  = "cat_photo.delete"
+)
+
+// Типы объектов действий
+const (
+	AuditTargetUser     = "user"
+	AuditTargetLogin    = "login"
+	AuditTargetSession  = "session"
+	AuditTargetCat      = "cat"
+	AuditTargetCatPhoto = "cat_photo"
+)
+
+// Результаты действий
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// Количество событий в ответе по умолчанию и максимальное
+const (
+	AuditEventsDefaultLimit = 50
+	AuditEventsMaxLimit     = 200
+)
+
+type AuditEvent struct {
+	ID             int64     `json:"id" db:"id"`
+	ActorID        int       `json:"actor_id,omitempty" db:"actor_id"`
+	ImpersonatorID int       `json:"impersonator_id,omitempty" db:"impersonator_id"`
+	Action         string    `json:"action" db:"action"`
+	TargetType     string    `json:"target_type,omitempty" db:"target_type"`
+	TargetID       string    `json:"target_id,omitempty" db:"target_id"`
+	Outcome        string    `json:"outcome" db:"outcome"`
+	IP             string    `json:"ip" db:"ip"`
+	UserAgent      string    `json:"user_agent" db:"user_agent"`
+	Details        string    `json:"details,omitempty" db:"details"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type AuditEventFilter struct {
+	ActorID        int    `query:"actor_id"`
+	ImpersonatorID int    `query:"impersonator_id"`
+	Action         string `query:"action"`
+	TargetType     string `query:"target_type"`
+	TargetID       string `query:"target_id"`
+	Outcome        string `query:"outcome"`
+	IP             string `query:"ip"`
+	From           string `query:"from"`
+	To             string `query:"to"`
+	BeforeID       int64  `query:"before_id"`
+	Limit          int    `query:"limit"`
+
+	FromTime time.Time `query:"-"`
+	ToTime   time.Time `query:"-"`
+}
+
+type AuditEventListResponse struct {
+	Events       []*AuditEvent `json:"events"`
+	NextBeforeID int64         `json:"next_before_id,omitempty"`
+}
@@ -1,6 +1,22 @@
 package entities
 
-import "mime/multipart"
+import (
+	"mime/multipart"
+	"time"
+)
+
+// Количество котов в ответе по умолчанию и максимальное
+const (
+	CatsDefaultLimit = 50
+	CatsMaxLimit     = 200
+)
+
+// Поля сортировки списка котов
+const (
+	CatSortName      = "name"
+	CatSortAge       = "age"
+	CatSortCreatedAt = "created_at"
+)
 
 type Cat struct {
 	ID          int    `json:"id" db:"id"`
@@ -22,11 +38,39 @@ type CatWithPhotos struct {
 }
 
 type CatWithPrimePhoto struct {
-	ID      int     `json:"id" db:"id"`
-	Name    string  `json:"name" db:"name"`
-	Age     int     `json:"age" db:"age"`
-	PhotoID *int    `json:"photo_id" db:"photo_id"`
-	Url     *string `json:"url" db:"url"`
+	ID         int     `json:"id" db:"id"`
+	Name       string  `json:"name" db:"name"`
+	Age        int     `json:"age" db:"age"`
+	CreatedBy  int     `json:"created_by" db:"created_by"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+	PhotoID    *int    `json:"photo_id" db:"photo_id"`
+	Url        *string `json:"url" db:"url"`
+	PhotoCount int     `json:"photo_count" db:"photo_count"`
+}
+
+// Фильтры списка котов. Для следующей страницы передается next_cursor из ответа с теми же фильтрами и сортировкой
+type CatListFilter struct {
+	AgeMin      *int   `query:"age_min"`
+	AgeMax      *int   `query:"age_max"`
+	OwnerID     int    `query:"owner_id"`
+	HasPhoto    *bool  `query:"has_photo"`
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	Sort        string `query:"sort"`
+	Order       string `query:"order"`
+	Cursor      string `query:"cursor"`
+	Limit       int    `query:"limit"`
+
+	CreatedFromTime time.Time `query:"-"`
+	CreatedToTime   time.Time `query:"-"`
+	CursorValue     any       `query:"-"`
+	CursorID        int       `query:"-"`
+}
+
+type CatListResponse struct {
+	Cats       []*CatWithPrimePhoto `json:"cats"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+	Total      int                  `json:"total"`
 }
 
 type CatCreateRequestWithPhotos struct {
@@ -0,0 +1,46 @@
+package entities
+
+import "time"
+
+// Статусы выгрузки данных пользователя
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusReady      = "ready"
+	DataExportStatusFailed     = "failed"
+)
+
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      int        `json:"user_id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type DataExportDownloadResponse struct {
+	Url       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Профиль пользователя в архиве выгрузки (profile.json)
+type DataExportProfile struct {
+	*UserGet
+	Role          string `json:"role"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// Опись архива выгрузки (manifest.json): фото, файлов которых нет в хранилище, в архив не попадают
+type DataExportManifest struct {
+	MissingPhotos []*CatPhoto `json:"missing_photos"`
+}
+
+// Кот пользователя с метаданными фото в архиве выгрузки (cats.json)
+type DataExportCat struct {
+	*Cat
+	Photos []*CatPhoto `json:"photos"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+const EmailVerificationTokenPurpose = "email_verification"
+
+type UserEmail struct {
+	Email    string `json:"email" db:"email"`
+	Verified bool   `json:"verified" db:"email_verified"`
+}
+
+type UserUpdateEmailRequest struct {
+	Email string `json:"email"`
+}
+
+type EmailVerificationConfirmRequest struct {
+	Token string `json:"token"`
+}
@@ -1,5 +1,58 @@
 package entities
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("invalid login or password")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrOIDCDisabled         = errors.New("oidc login is disabled")
+	ErrAccountDeleted       = errors.New("account is scheduled for deletion")
+	ErrRegistrationClosed   = errors.New("registration is closed")
+	ErrInvitationRequired   = errors.New("invitation code required")
+	ErrInvalidInvitation    = errors.New("invalid or expired invitation code")
+	ErrInvalidEmail         = errors.New("invalid email")
+	ErrEmailTaken           = errors.New("email already in use")
+	ErrDataExportNotFound   = errors.New("data export not found")
+	ErrDataExportInProgress = errors.New("data export already in progress")
+	ErrDataExportNotReady   = errors.New("data export is not ready")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrPhotoFileNotFound    = errors.New("photo file not found")
+	ErrInvalidRefreshToken  = errors.New("invalid or revoked refresh token")
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyExpired        = errors.New("api key expired")
+)
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// Ошибка проверки параметров запроса (фильтры, сортировка, курсор)
+type ValidationError struct {
+	Message string
+}
+
+func NewValidationError(format string, args ...any) *ValidationError {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", e.RetryAfterSeconds())
+}
+
+func (e *LoginLockedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
@@ -0,0 +1,38 @@
+package entities
+
+import "time"
+
+// Режимы регистрации
+const (
+	RegistrationModeOpen       = "open"
+	RegistrationModeInviteOnly = "invite_only"
+	RegistrationModeClosed     = "closed"
+)
+
+// Префикс кода приглашения
+const InvitationCodePrefix = "inv_"
+
+// Максимальное число использований одного приглашения
+const InvitationMaxUses = 1000
+
+type Invitation struct {
+	ID        int        `json:"id" db:"id"`
+	CreatedBy int        `json:"created_by" db:"created_by"`
+	Prefix    string     `json:"prefix" db:"code_prefix"`
+	Role      string     `json:"role,omitempty" db:"role"`
+	MaxUses   int        `json:"max_uses" db:"max_uses"`
+	Uses      int        `json:"uses" db:"uses"`
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt string     `json:"created_at" db:"created_at"`
+}
+
+type InvitationCreateRequest struct {
+	MaxUses   int        `json:"max_uses,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Role      string     `json:"role,omitempty"`
+}
+
+type InvitationCreateResponse struct {
+	*Invitation
+	Code string `json:"code"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+type LoginProtectionPolicy struct {
+	MaxAttemptsPerLogin int
+	MaxAttemptsPerIP    int
+	AttemptsWindow      time.Duration
+	LockoutDuration     time.Duration
+	MaxLockoutDuration  time.Duration
+}
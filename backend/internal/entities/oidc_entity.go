@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Discovery документ провайдера (/.well-known/openid-configuration)
+type OIDCDiscovery struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// Ответ token endpoint провайдера
+type OIDCTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type OIDCIDTokenClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// Состояние входа через провайдера, хранится в кеше до возврата пользователя на callback
+type OIDCState struct {
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	LinkUserID   int    `json:"link_user_id,omitempty"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type OIDCCallbackRequest struct {
+	Code             string `query:"code"`
+	State            string `query:"state"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+}
+
+type UserIdentity struct {
+	ID        int    `json:"id" db:"id"`
+	UserID    int    `json:"-" db:"user_id"`
+	Provider  string `json:"provider" db:"provider"`
+	Subject   string `json:"subject" db:"subject"`
+	Email     string `json:"email,omitempty" db:"email"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
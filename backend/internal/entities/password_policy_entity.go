@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Коды нарушений политики паролей
+const (
+	PasswordTooShort         = "too_short"
+	PasswordTooLong          = "too_long"
+	PasswordMissingLowercase = "missing_lowercase"
+	PasswordMissingUppercase = "missing_uppercase"
+	PasswordMissingDigit     = "missing_digit"
+	PasswordMissingSymbol    = "missing_symbol"
+	PasswordEqualsLogin      = "equals_login"
+	PasswordBreached         = "breached"
+)
+
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	ForbidLogin      bool
+	CheckBreached    bool
+}
+
+type PasswordPolicyViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Ошибка проверки пароля со списком всех нарушенных правил
+type PasswordPolicyError struct {
+	Violations []*PasswordPolicyViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		codes = append(codes, violation.Code)
+	}
+	return fmt.Sprintf("password does not meet policy requirements: %s", strings.Join(codes, ", "))
+}
+
+type PasswordPolicyErrorResponse struct {
+	Error      string                     `json:"error"`
+	Violations []*PasswordPolicyViolation `json:"violations"`
+}
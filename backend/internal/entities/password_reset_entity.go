@@ -0,0 +1,12 @@
+package entities
+
+const PasswordResetTokenPurpose = "password_reset"
+
+type PasswordResetRequest struct {
+	Login string `json:"login"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,40 @@
+package entities
+
+import "io"
+
+// Ограничения полей профиля (в символах) и размера аватара (в байтах)
+const (
+	ProfileDisplayNameMaxLength = 64
+	ProfileBioMaxLength         = 500
+	ProfileLocationMaxLength    = 100
+	AvatarMaxSize               = 5 * 1024 * 1024
+)
+
+// Публичный профиль пользователя, доступен любому авторизованному пользователю
+type UserProfile struct {
+	ID          int     `json:"id" db:"id"`
+	Login       string  `json:"login" db:"login"`
+	DisplayName string  `json:"display_name" db:"display_name"`
+	Bio         string  `json:"bio" db:"bio"`
+	Location    string  `json:"location" db:"location"`
+	AvatarUrl   *string `json:"avatar_url,omitempty" db:"avatar_url"`
+	CreatedAt   string  `json:"created_at" db:"created_at"`
+}
+
+// Изменение профиля, поля без значения не изменяются. Пустая строка очищает поле
+type UserProfileUpdateRequest struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	Location    *string `json:"location,omitempty"`
+}
+
+type AvatarUploadRequest struct {
+	File     io.Reader
+	FileSize int64  `json:"file_size" db:"file_size"`
+	FileName string `json:"file_name" db:"file_name"`
+	MimeType string `json:"mime_type" db:"mime_type"`
+}
+
+type AvatarUploadResponse struct {
+	AvatarUrl string `json:"avatar_url"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+// Роль moderator зарезервирована: отдельных прав у нее пока нет, ее можно указать в INVITATION_CREATOR_ROLE
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Уровни ролей: роль с большим уровнем имеет все права ролей с меньшим уровнем
+var roleLevels = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+type UserUpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// Проверка, что роль не ниже требуемой
+
+func HasRole(role, requiredRole string) bool {
+	return roleLevels[role] >= roleLevels[requiredRole]
+}
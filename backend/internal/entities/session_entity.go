@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+type Session struct {
+	ID            string    `json:"id"`
+	UserAgent     string    `json:"user_agent"`
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastRefreshAt time.Time `json:"last_refresh_at"`
+	IsCurrent     bool      `json:"is_current"`
+}
+
+type ClientInfo struct {
+	UserAgent      string
+	IP             string
+	ImpersonatorID int
+}
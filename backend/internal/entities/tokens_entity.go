@@ -1,22 +1,29 @@
 package entities
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
-	AccessTokenType  = "access_token"
-	RefreshTokenType = "refresh_token"
+	AccessTokenType       = "access_token"
+	RefreshTokenType      = "refresh_token"
+	MFAChallengeTokenType = "mfa_challenge"
 )
 
 type AuthResponse struct {
 	*TokenPair
-	UserID int `json:"id"`
+	UserID         int    `json:"id"`
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
 }
 
 type TokenPair struct {
-	AccessToken  string `json:"access_token" cookie:"access_token"`
-	RefreshToken string `json:"refresh_token" cookie:"refresh_token"`
+	AccessToken    string `json:"access_token" cookie:"access_token"`
+	RefreshToken   string `json:"refresh_token" cookie:"refresh_token"`
+	AccessTokenID  string `json:"-"`
+	RefreshTokenID string `json:"-"`
 }
 
 type RefreshTokenRequest struct {
@@ -28,7 +35,53 @@ type LogoutTokenRequest struct {
 }
 
 type TokenClaims struct {
-	UserID int    `json:"user_id"`
-	Type   string `json:"type"`
+	UserID    int         `json:"user_id"`
+	SessionID string      `json:"sid"`
+	FamilyID  string      `json:"fid"`
+	Role      string      `json:"role"`
+	Type      string      `json:"type"`
+	Actor     *TokenActor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// Пользователь, действующий от имени владельца токена (claim act, RFC 8693)
+type TokenActor struct {
+	UserID int `json:"user_id"`
+}
+
+type TokenSubject struct {
+	UserID    int
+	SessionID string
+	FamilyID  string
+	Role      string
+	Actor     *TokenActor
+}
+
+// Одноразовый токен (сброс пароля, подтверждение email). Email - адрес, для подтверждения которого выдан токен
+type OneTimeToken struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+}
+
+type ImpersonationResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      int       `json:"id"`
+	ActorID     int       `json:"actor_id"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
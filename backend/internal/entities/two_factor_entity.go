@@ -0,0 +1,25 @@
+package entities
+
+type TwoFactor struct {
+	Secret       string `json:"-" db:"totp_secret"`
+	Enabled      bool   `json:"enabled" db:"totp_enabled"`
+	LastUsedStep int64  `json:"-" db:"totp_last_step"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
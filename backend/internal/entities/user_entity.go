@@ -1,16 +1,22 @@
 package entities
 
+import "time"
+
 type User struct {
-	ID           int    `json:"id" db:"id"`
-	Login        string `json:"login" db:"login"`
-	Password     string `json:"password" db:"password"`
-	PasswordHash string `json:"password_hash" db:"password_hash"`
-	CreatedAt    string `json:"created_at" db:"created_at"`
+	ID            int    `json:"id" db:"id"`
+	Login         string `json:"login" db:"login"`
+	Email         string `json:"email" db:"email"`
+	EmailVerified bool   `json:"email_verified" db:"email_verified"`
+	Password      string `json:"password" db:"password"`
+	PasswordHash  string `json:"password_hash" db:"password_hash"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
 }
 
 type UserCreateRequest struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login          string `json:"login"`
+	Email          string `json:"email,omitempty"`
+	Password       string `json:"password"`
+	InvitationCode string `json:"invitation_code,omitempty"`
 }
 
 type UserLoginRequest struct {
@@ -18,16 +24,80 @@ type UserLoginRequest struct {
 	Password string `json:"password"`
 }
 
+// Публичные данные пользователя. Роль нужна сервисам, но не отдается: иначе любой пользователь мог бы найти администраторов
 type UserGet struct {
-	ID        int    `json:"id" db:"id"`
-	Login     string `json:"login" db:"login"`
-	CreatedAt string `json:"created_at" db:"created_at"`
+	ID          int     `json:"id" db:"id"`
+	Login       string  `json:"login" db:"login"`
+	Role        string  `json:"-" db:"role"`
+	DisplayName string  `json:"display_name" db:"display_name"`
+	Bio         string  `json:"bio" db:"bio"`
+	Location    string  `json:"location" db:"location"`
+	AvatarUrl   *string `json:"avatar_url,omitempty" db:"avatar_url"`
+	CreatedAt   string  `json:"created_at" db:"created_at"`
+}
+
+// Количество пользователей в ответе по умолчанию и максимальное
+const (
+	UsersDefaultLimit = 50
+	UsersMaxLimit     = 200
+)
+
+// Поиск по логину: по началу логина (по умолчанию) или по вхождению, без учета регистра
+const (
+	UserSearchPrefix   = "prefix"
+	UserSearchContains = "contains"
+)
+
+// Порядок сортировки
+const (
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// Список пользователей сортируется по created_at, новые первыми по умолчанию.
+// Для следующей страницы передается next_cursor из ответа
+type UserListFilter struct {
+	Search     string `query:"search"`
+	SearchMode string `query:"search_mode"`
+	Order      string `query:"order"`
+	Cursor     string `query:"cursor"`
+	Limit      int    `query:"limit"`
+
+	CursorCreatedAt time.Time `query:"-"`
+	CursorID        int       `query:"-"`
+}
+
+// Пользователь в списке для администратора, вместе с ролью
+type UserListItem struct {
+	*UserGet
+	Role string `json:"role"`
+}
+
+type UserListResponse struct {
+	Users      []*UserListItem `json:"users"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Total      int             `json:"total"`
+}
+
+// Запрос на удаление аккаунта. Пользователь удаляется вместе с котиками и файлами после purge_after
+type UserDeletion struct {
+	RequestedAt    time.Time  `json:"requested_at" db:"deletion_requested_at"`
+	RequestedBy    int        `json:"requested_by" db:"deletion_requested_by"`
+	PurgeAfter     time.Time  `json:"purge_after" db:"purge_after"`
+	PurgeStartedAt *time.Time `json:"purge_started_at,omitempty" db:"purge_started_at"`
+}
+
+type UserDeleteResponse struct {
+	ID         int       `json:"id"`
+	PurgeAfter time.Time `json:"purge_after"`
 }
 
 type UserUpdatePasswordRequest struct {
-	Password string `json:"password" db:"password"`
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password" db:"password"`
 }
 
 type UserUpdatePasswordResponse struct {
+	*TokenPair
 	ID int `json:"id" db:"id"`
 }
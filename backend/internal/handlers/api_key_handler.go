@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type APIKeyHandler interface {
+	CreateAPIKey(c *fiber.Ctx) error
+	GetAllAPIKeys(c *fiber.Ctx) error
+	DeleteAPIKey(c *fiber.Ctx) error
+}
+
+type apiKeyHandlerImpl struct {
+	apiKeyService  services.APIKeyService
+	requestTimeout time.Duration
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService, requestTimeout time.Duration) APIKeyHandler {
+	return &apiKeyHandlerImpl{apiKeyService: apiKeyService, requestTimeout: requestTimeout}
+}
+
+// CreateAPIKey
+// @Summary Создание API ключа
+// @Description Создает API ключ с указанными scopes (cats:read, cats:write, photos:write) и необязательным сроком действия.
+// @Description Ключ возвращается один раз, передается в заголовке "Authorization: ApiKey <key>"
+// @Tags api keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param apiKey body entities.APIKeyCreateRequest true "Данные ключа"
+// @Success 201 {object} entities.APIKeyCreateResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Router /auth/apikey/create [post]
+func (h *apiKeyHandlerImpl) CreateAPIKey(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	apiKeyCreateRequest := &entities.APIKeyCreateRequest{}
+	if err := c.BodyParser(&apiKeyCreateRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Создаем ключ
+	apiKeyCreateResponse, err := h.apiKeyService.CreateAPIKey(ctx, userID, apiKeyCreateRequest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apiKeyCreateResponse)
+}
+
+// GetAllAPIKeys
+// @Summary Получение API ключей
+// @Description Возвращает все API ключи пользователя (без самих ключей)
+// @Tags api keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} []entities.APIKey
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/apikey/all [get]
+func (h *apiKeyHandlerImpl) GetAllAPIKeys(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем ключи
+	apiKeys, err := h.apiKeyService.GetAllAPIKeys(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apiKeys)
+}
+
+// DeleteAPIKey
+// @Summary Удаление API ключа
+// @Description Удаляет API ключ пользователя, ключ сразу перестает действовать
+// @Tags api keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Router /auth/apikey/{id} [delete]
+func (h *apiKeyHandlerImpl) DeleteAPIKey(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	apiKeyID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Удаляем ключ
+	err = h.apiKeyService.DeleteAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully deleted api key")
+}
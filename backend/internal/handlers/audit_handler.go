@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+)
+
+type AuditHandler interface {
+	GetAuditEvents(c *fiber.Ctx) error
+}
+
+type auditHandlerImpl struct {
+	auditLogger    services.AuditLogger
+	requestTimeout time.Duration
+}
+
+func NewAuditHandler(auditLogger services.AuditLogger, requestTimeout time.Duration) AuditHandler {
+	return &auditHandlerImpl{auditLogger: auditLogger, requestTimeout: requestTimeout}
+}
+
+// GetAuditEvents
+// @Summary Журнал аудита
+// @Description Возвращает события журнала аудита (вход, регистрация, удаление пользователей, изменения котиков и фото), новые первыми.
+// @Description Для следующей страницы передайте next_before_id из ответа в before_id. Только для администраторов
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param actor_id query int false "ID пользователя, выполнившего действие"
+// @Param impersonator_id query int false "ID администратора, выполнившего действие от имени пользователя"
+// @Param action query string false "Действие (например, user.login)"
+// @Param target_type query string false "Тип объекта (user, login, session, cat, cat_photo)"
+// @Param target_id query string false "ID объекта"
+// @Param outcome query string false "Результат (success, failure)"
+// @Param ip query string false "IP адрес"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода (RFC 3339)"
+// @Param before_id query int false "Вернуть события с ID меньше указанного"
+// @Param limit query int false "Количество событий (по умолчанию 50, максимум 200)"
+// @Success 200 {object} entities.AuditEventListResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/admin/audit [get]
+func (h *auditHandlerImpl) GetAuditEvents(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим фильтры из параметров запроса
+	auditEventFilter := &entities.AuditEventFilter{}
+	if err := c.QueryParser(auditEventFilter); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// Получаем события
+	auditEventListResponse, err := h.auditLogger.GetAuditEvents(ctx, auditEventFilter)
+	if err != nil {
+		// Неверные фильтры или курсор
+		var validationErr *entities.ValidationError
+		if errors.As(err, &validationErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(auditEventListResponse)
+}
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,29 +15,43 @@ import (
 type AuthHandler interface {
 	Register(c *fiber.Ctx) error
 	Login(c *fiber.Ctx) error
+	LoginTwoFactor(c *fiber.Ctx) error
 	Refresh(c *fiber.Ctx) error
 	Logout(c *fiber.Ctx) error
+	GetAllSessions(c *fiber.Ctx) error
+	DeleteSession(c *fiber.Ctx) error
+	DeleteOtherSessions(c *fiber.Ctx) error
+	UpdateUserPassword(c *fiber.Ctx) error
+	RequestPasswordReset(c *fiber.Ctx) error
+	ConfirmPasswordReset(c *fiber.Ctx) error
 	DeleteUser(c *fiber.Ctx) error
+	AdminUpdateUserRole(c *fiber.Ctx) error
+	AdminDeleteUser(c *fiber.Ctx) error
+	AdminImpersonateUser(c *fiber.Ctx) error
+	JWKS(c *fiber.Ctx) error
 }
 
 type authHandlerImpl struct {
 	authService    services.AuthService
+	cookieOptions  *utils.TokenCookieOptions
 	requestTimeout time.Duration
 }
 
-func NewAuthHandler(authService services.AuthService, requestTimeout time.Duration) AuthHandler {
-	return &authHandlerImpl{authService: authService, requestTimeout: requestTimeout}
+func NewAuthHandler(authService services.AuthService, cookieOptions *utils.TokenCookieOptions, requestTimeout time.Duration) AuthHandler {
+	return &authHandlerImpl{authService: authService, cookieOptions: cookieOptions, requestTimeout: requestTimeout}
 }
 
 // Register
 // @Summary Создание пользователя
-// @Description Создает нового пользователя в системе и возвращает access и refresh токены
+// @Description Создает нового пользователя в системе и возвращает access и refresh токены (в режиме cookie - устанавливает HttpOnly cookie).
+// @Description В режиме регистрации invite_only требуется код приглашения, в режиме closed регистрация запрещена
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body entities.UserCreateRequest true "Данные пользователя"
 // @Success 201 {object} entities.AuthResponse
-// @Failure 400 {object} entities.ErrorResponse
+// @Failure 400 {object} entities.PasswordPolicyErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
 // @Failure 500 {object} entities.ErrorResponse
 // @Router /register [post]
 func (h *authHandlerImpl) Register(c *fiber.Ctx) error {
@@ -50,23 +67,49 @@ func (h *authHandlerImpl) Register(c *fiber.Ctx) error {
 	}
 
 	// Регистрируем пользователя и получаем токены
-	authResponse, err := h.authService.RegistrationUser(ctx, userCreateRequest)
+	authResponse, err := h.authService.RegistrationUser(ctx, userCreateRequest, utils.GetClientInfo(c))
 	if err != nil {
+		// Пароль не соответствует политике паролей
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(&entities.PasswordPolicyErrorResponse{Error: err.Error(), Violations: policyErr.Violations})
+		}
+
+		// Регистрация закрыта, код приглашения не передан или недействителен
+		if errors.Is(err, entities.ErrRegistrationClosed) || errors.Is(err, entities.ErrInvitationRequired) || errors.Is(err, entities.ErrInvalidInvitation) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Неверный формат email
+		if errors.Is(err, entities.ErrInvalidEmail) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// В режиме cookie токены передаются только в HttpOnly cookie
+	if h.cookieOptions != nil {
+		utils.SetTokenCookies(c, authResponse.TokenPair, h.cookieOptions)
+		authResponse.TokenPair = nil
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(authResponse)
 }
 
 // Login
 // @Summary Вход в аккаунт пользователя
-// @Description Вход в аккаунт пользователя, возвращает access и refresh токены
+// @Description Вход в аккаунт пользователя, возвращает access и refresh токены (в режиме cookie - устанавливает HttpOnly cookie).
+// @Description Если у пользователя включена 2FA, вместо токенов возвращается challenge_token для /login/2fa
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body entities.UserLoginRequest true "Данные пользователя"
 // @Success 200 {object} entities.AuthResponse
 // @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 429 {object} entities.ErrorResponse
 // @Failure 500 {object} entities.ErrorResponse
 // @Router /login [post]
 func (h *authHandlerImpl) Login(c *fiber.Ctx) error {
@@ -82,23 +125,97 @@ func (h *authHandlerImpl) Login(c *fiber.Ctx) error {
 	}
 
 	// Авторизуем пользователя
-	authResponse, err := h.authService.LoginUser(ctx, userLoginRequest)
+	authResponse, err := h.authService.LoginUser(ctx, userLoginRequest, utils.GetClientInfo(c))
 	if err != nil {
+		// Вход временно заблокирован после неудачных попыток
+		var lockErr *entities.LoginLockedError
+		if errors.As(err, &lockErr) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(lockErr.RetryAfterSeconds()))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Неверный логин или пароль
+		if errors.Is(err, entities.ErrInvalidCredentials) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Аккаунт удален администратором
+		if errors.Is(err, entities.ErrAccountDeleted) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// В режиме cookie токены передаются только в HttpOnly cookie
+	if h.cookieOptions != nil && authResponse.TokenPair != nil {
+		utils.SetTokenCookies(c, authResponse.TokenPair, h.cookieOptions)
+		authResponse.TokenPair = nil
+	}
+
+	return c.Status(fiber.StatusOK).JSON(authResponse)
+}
+
+// LoginTwoFactor
+// @Summary Второй шаг входа с 2FA
+// @Description Обменивает challenge токен из /login и код из приложения (или код восстановления) на access и refresh токены
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body entities.TwoFactorLoginRequest true "Challenge токен и код"
+// @Success 200 {object} entities.AuthResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 429 {object} entities.ErrorResponse
+// @Router /login/2fa [post]
+func (h *authHandlerImpl) LoginTwoFactor(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	twoFactorLoginRequest := &entities.TwoFactorLoginRequest{}
+	if err := c.BodyParser(&twoFactorLoginRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// Проверяем код и выдаем токены
+	authResponse, err := h.authService.LoginUserTwoFactor(ctx, twoFactorLoginRequest, utils.GetClientInfo(c))
+	if err != nil {
+		// Вход временно заблокирован после неудачных попыток
+		var lockErr *entities.LoginLockedError
+		if errors.As(err, &lockErr) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(lockErr.RetryAfterSeconds()))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if errors.Is(err, entities.ErrAccountDeleted) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// В режиме cookie токены передаются только в HttpOnly cookie
+	if h.cookieOptions != nil {
+		utils.SetTokenCookies(c, authResponse.TokenPair, h.cookieOptions)
+		authResponse.TokenPair = nil
+	}
+
 	return c.Status(fiber.StatusOK).JSON(authResponse)
 }
 
 // Refresh
 // @Summary Обновление токенов
-// @Description Обновляет access и refresh токены
+// @Description Обновляет access и refresh токены. В режиме cookie refresh токен берется из cookie, требуется заголовок X-CSRF-Token
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param token body entities.RefreshTokenRequest true "Refresh токен"
+// @Param token body entities.RefreshTokenRequest false "Refresh токен"
 // @Success 201 {object} entities.TokenPair
 // @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
 // @Failure 500 {object} entities.ErrorResponse
 // @Router /refresh [post]
 func (h *authHandlerImpl) Refresh(c *fiber.Ctx) error {
@@ -107,29 +224,38 @@ func (h *authHandlerImpl) Refresh(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
 	defer cancel()
 
-	// Получаем refresh токен из тела
+	// Получаем refresh токен из тела или cookie
 	refreshTokenRequest := &entities.RefreshTokenRequest{}
-	if err := c.BodyParser(&refreshTokenRequest); err != nil {
+	if err := h.parseRefreshToken(c, refreshTokenRequest); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid input"})
 	}
 
 	// Обновляем токены
-	tokenPair, err := h.authService.RefreshToken(ctx, refreshTokenRequest.RefreshToken)
-	if err != nil {
+	tokenPair, err := h.authService.RefreshToken(ctx, refreshTokenRequest.RefreshToken, utils.GetClientInfo(c))
+	if errors.Is(err, entities.ErrInvalidRefreshToken) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// В режиме cookie токены передаются только в HttpOnly cookie
+	if h.cookieOptions != nil {
+		utils.SetTokenCookies(c, tokenPair, h.cookieOptions)
+		return c.Status(fiber.StatusCreated).SendString("Successfully refreshed tokens")
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(tokenPair)
 }
 
 // Logout
 // @Summary Удаление токена
-// @Description Удаляет refresh токен
+// @Description Завершает текущую сессию: отзывает ее access токены и refresh токены. В режиме cookie refresh токен берется из cookie, требуется заголовок X-CSRF-Token.
+// @Description Если refresh токен недействителен или выдан для другой сессии, возвращается 400, но текущая сессия все равно завершается
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param token body entities.LogoutTokenRequest true "Refresh токен"
+// @Param token body entities.LogoutTokenRequest false "Refresh токен"
 // @Success 200 {object} string
 // @Failure 400 {object} entities.ErrorResponse
 // @Failure 401 {object} entities.ErrorResponse
@@ -141,31 +267,267 @@ func (h *authHandlerImpl) Logout(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
 	defer cancel()
 
-	userID := c.Locals("userID").(int)
+	tokenClaims := c.Locals("tokenClaims").(*entities.TokenClaims)
 
-	// Получаем refresh токен из тела
+	// Получаем refresh токен из тела или cookie
 	logoutTokenRequest := &entities.LogoutTokenRequest{}
-	if err := c.BodyParser(&logoutTokenRequest); err != nil {
+	if err := h.parseRefreshToken(c, logoutTokenRequest); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid input"})
 	}
 
-	// Удаляем refresh токен
-	err := h.authService.DeleteRefreshToken(ctx, userID, logoutTokenRequest.RefreshToken)
-	if err != nil {
+	// Отзываем текущий access токен и завершаем сессию
+	revoked, err := h.authService.DeleteRefreshToken(ctx, tokenClaims, logoutTokenRequest.RefreshToken, utils.GetClientInfo(c))
+
+	// Access токен отозван - удаляем cookie с токенами, даже если дальше произошла ошибка
+	if revoked {
+		h.clearTokenCookies(c)
+	}
+
+	// Refresh токен не найден или выдан для другой сессии (текущая сессия все равно завершена)
+	if errors.Is(err, entities.ErrInvalidRefreshToken) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": entities.ErrInvalidRefreshToken.Error()})
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.Status(fiber.StatusOK).SendString("Successfully logged out")
 }
 
+// GetAllSessions
+// @Summary Получение активных сессий
+// @Description Возвращает все активные сессии (устройства) пользователя
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} []entities.Session
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/session/all [get]
+func (h *authHandlerImpl) GetAllSessions(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	tokenClaims := c.Locals("tokenClaims").(*entities.TokenClaims)
+
+	// Получаем все сессии пользователя
+	sessions, err := h.authService.GetAllSessions(ctx, tokenClaims)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sessions)
+}
+
+// DeleteSession
+// @Summary Завершение сессии
+// @Description Завершает сессию пользователя по ID, отзывая ее refresh и access токены
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/session/{id} [delete]
+func (h *authHandlerImpl) DeleteSession(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем ID сессии из параметров
+	sessionID := c.Params("id")
+	if sessionID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing id"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Завершаем сессию
+	err := h.authService.DeleteSession(ctx, userID, sessionID)
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully deleted session")
+}
+
+// DeleteOtherSessions
+// @Summary Выход на всех других устройствах
+// @Description Завершает все сессии пользователя, кроме текущей
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} string
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/session/others [delete]
+func (h *authHandlerImpl) DeleteOtherSessions(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	tokenClaims := c.Locals("tokenClaims").(*entities.TokenClaims)
+
+	// Завершаем все сессии, кроме текущей
+	err := h.authService.DeleteOtherSessions(ctx, tokenClaims)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully deleted other sessions")
+}
+
+// UpdateUserPassword
+// @Summary обновление пароля пользователя
+// @Description обновление пароля пользователя, требует текущий пароль. Завершает все остальные сессии пользователя,
+// @Description текущая сессия сохраняется, в ответе новые access и refresh токены (в режиме cookie - устанавливает HttpOnly cookie)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user body entities.UserUpdatePasswordRequest true "Данные пользователя"
+// @Success 200 {object} entities.UserUpdatePasswordResponse
+// @Failure 400 {object} entities.PasswordPolicyErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 429 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/password [patch]
+func (h *authHandlerImpl) UpdateUserPassword(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	userUpdatePasswordRequest := &entities.UserUpdatePasswordRequest{}
+	if err := c.BodyParser(&userUpdatePasswordRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	tokenClaims := c.Locals("tokenClaims").(*entities.TokenClaims)
+
+	// Обновляем пароль пользователя, завершаем остальные сессии и получаем новые токены
+	tokenPair, err := h.authService.UpdateUserPassword(ctx, tokenClaims, userUpdatePasswordRequest, utils.GetClientInfo(c))
+	if err != nil {
+		// Проверка пароля временно заблокирована после неудачных попыток
+		var lockErr *entities.LoginLockedError
+		if errors.As(err, &lockErr) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(lockErr.RetryAfterSeconds()))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Неверный текущий пароль
+		if errors.Is(err, entities.ErrInvalidCredentials) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Пароль не соответствует политике паролей
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(&entities.PasswordPolicyErrorResponse{Error: err.Error(), Violations: policyErr.Violations})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// В режиме cookie токены передаются только в HttpOnly cookie
+	if h.cookieOptions != nil {
+		utils.SetTokenCookies(c, tokenPair, h.cookieOptions)
+		tokenPair = nil
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&entities.UserUpdatePasswordResponse{TokenPair: tokenPair, ID: tokenClaims.UserID})
+}
+
+// RequestPasswordReset
+// @Summary Запрос сброса пароля
+// @Description Отправляет на email пользователя одноразовую ссылку для сброса пароля. Ответ не зависит от того, существует ли пользователь
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body entities.PasswordResetRequest true "Логин пользователя"
+// @Success 202 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /password/reset [post]
+func (h *authHandlerImpl) RequestPasswordReset(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	passwordResetRequest := &entities.PasswordResetRequest{}
+	if err := c.BodyParser(&passwordResetRequest); err != nil || passwordResetRequest.Login == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// Отправляем письмо со ссылкой для сброса пароля
+	err := h.authService.RequestPasswordReset(ctx, passwordResetRequest)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusAccepted).SendString("If the account exists, a password reset link has been sent")
+}
+
+// ConfirmPasswordReset
+// @Summary Подтверждение сброса пароля
+// @Description Устанавливает новый пароль по одноразовому токену из письма и завершает все сессии пользователя
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body entities.PasswordResetConfirmRequest true "Токен сброса и новый пароль"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.PasswordPolicyErrorResponse
+// @Router /password/reset/confirm [post]
+func (h *authHandlerImpl) ConfirmPasswordReset(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	passwordResetConfirmRequest := &entities.PasswordResetConfirmRequest{}
+	if err := c.BodyParser(&passwordResetConfirmRequest); err != nil || passwordResetConfirmRequest.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// Устанавливаем новый пароль
+	err := h.authService.ConfirmPasswordReset(ctx, passwordResetConfirmRequest, utils.GetClientInfo(c))
+	if err != nil {
+		// Пароль не соответствует политике паролей
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(&entities.PasswordPolicyErrorResponse{Error: err.Error(), Violations: policyErr.Violations})
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully reset password")
+}
+
 // DeleteUser
 // @Summary Удаление пользователя
-// @Description Удаляет пользователя из системы и отзывает все refresh токены
+// @Description Помечает пользователя на удаление и отзывает все access и refresh токены. До purge_after удаление можно отменить,
+// @Description войдя в аккаунт, после - пользователь, его котики и фото удаляются безвозвратно
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} string
+// @Success 202 {object} entities.UserDeleteResponse
 // @Failure 401 {object} entities.ErrorResponse
 // @Failure 500 {object} entities.ErrorResponse
 // @Router /auth/user/delete [delete]
@@ -177,11 +539,163 @@ func (h *authHandlerImpl) DeleteUser(c *fiber.Ctx) error {
 
 	userID := c.Locals("userID").(int)
 
-	// Удаляем пользователя
-	err := h.authService.DeleteUser(ctx, userID)
+	// Помечаем пользователя на удаление
+	userDeleteResponse, err := h.authService.DeleteUser(ctx, userID, userID, utils.GetClientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Удаляем cookie с отозванными токенами
+	h.clearTokenCookies(c)
+
+	return c.Status(fiber.StatusAccepted).JSON(userDeleteResponse)
+}
+
+// AdminUpdateUserRole
+// @Summary Изменение роли пользователя
+// @Description Устанавливает роль пользователя (user, moderator, admin) и отзывает его токены. Только для администраторов
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param role body entities.UserUpdateRoleRequest true "Новая роль"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Router /auth/admin/user/{id}/role [patch]
+func (h *authHandlerImpl) AdminUpdateUserRole(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	userID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Парсим тело запроса в структуру
+	userUpdateRoleRequest := &entities.UserUpdateRoleRequest{}
+	if err = c.BodyParser(&userUpdateRoleRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	actorID := c.Locals("userID").(int)
+
+	// Обновляем роль пользователя
+	err = h.authService.UpdateUserRole(ctx, actorID, userID, userUpdateRoleRequest, utils.GetClientInfo(c))
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully updated user role")
+}
+
+// AdminDeleteUser
+// @Summary Удаление любого пользователя
+// @Description Помечает пользователя на удаление и отзывает все его токены. Войти в аккаунт, удаленный администратором, нельзя,
+// @Description после purge_after пользователь, его котики и фото удаляются безвозвратно. Только для администраторов
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 202 {object} entities.UserDeleteResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/admin/user/{id} [delete]
+func (h *authHandlerImpl) AdminDeleteUser(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	userID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	actorID := c.Locals("userID").(int)
+
+	// Помечаем пользователя на удаление
+	userDeleteResponse, err := h.authService.DeleteUser(ctx, actorID, userID, utils.GetClientInfo(c))
+	if errors.Is(err, entities.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).SendString("Successfully deleted user")
+	return c.Status(fiber.StatusAccepted).JSON(userDeleteResponse)
+}
+
+// AdminImpersonateUser
+// @Summary Вход от имени пользователя
+// @Description Выдает короткоживущий access токен от имени пользователя (без refresh токена) с claim act, содержащим ID администратора.
+// @Description По токену нельзя сменить пароль, удалить аккаунт и изменить способы входа. Токен возвращается только в теле ответа,
+// @Description в том числе в режиме cookie. Все действия по токену записываются в журнал аудита с impersonator_id. Только для администраторов
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} entities.ImpersonationResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Router /auth/admin/user/{id}/impersonate [post]
+func (h *authHandlerImpl) AdminImpersonateUser(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	userID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	actorID := c.Locals("userID").(int)
+
+	// Выдаем токен имперсонации
+	impersonationResponse, err := h.authService.ImpersonateUser(ctx, actorID, userID, utils.GetClientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(impersonationResponse)
+}
+
+// JWKS
+// @Summary Публичные ключи подписи токенов
+// @Description Возвращает публичные ключи в формате JWKS для проверки jwt токенов другими сервисами
+// @Tags auth
+// @Produce json
+// @Success 200 {object} entities.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *authHandlerImpl) JWKS(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.authService.GetJWKS())
+}
+
+// Получение refresh токена из cookie (в режиме cookie) или из тела запроса
+
+func (h *authHandlerImpl) parseRefreshToken(c *fiber.Ctx, out any) error {
+	if h.cookieOptions != nil {
+		return c.CookieParser(out)
+	}
+	return c.BodyParser(out)
+}
+
+// Удаление cookie с токенами (в режиме cookie)
+
+func (h *authHandlerImpl) clearTokenCookies(c *fiber.Ctx) {
+	if h.cookieOptions != nil {
+		utils.ClearTokenCookies(c, h.cookieOptions)
+	}
 }
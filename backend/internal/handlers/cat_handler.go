@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"github.com/unwelcome/iqjtest/pkg/utils"
 	"time"
 
@@ -14,6 +15,8 @@ type CatHandler interface {
 	CreateCat(c *fiber.Ctx) error
 	GetCatByID(c *fiber.Ctx) error
 	GetAllCats(c *fiber.Ctx) error
+	GetMyCats(c *fiber.Ctx) error
+	GetUserCats(c *fiber.Ctx) error
 	UpdateCatName(c *fiber.Ctx) error
 	UpdateCatAge(c *fiber.Ctx) error
 	UpdateCatDescription(c *fiber.Ctx) error
@@ -74,7 +77,7 @@ func (h *catHandlerImpl) CreateCat(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int)
 
 	// Создаем кота
-	createCatResponse, err := h.catService.CreateCat(ctx, userID, createCatRequest)
+	createCatResponse, err := h.catService.CreateCat(ctx, userID, createCatRequest, utils.GetClientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -118,12 +121,24 @@ func (h *catHandlerImpl) GetCatByID(c *fiber.Ctx) error {
 
 // GetAllCats
 // @Summary Получение всех котов
-// @Description Получение всех котов
+// @Description Получение котов постранично с главным фото и количеством фото. Сортировка по дате создания (по умолчанию, новые первыми),
+// @Description кличке или возрасту (по возрастанию). Для следующей страницы передайте next_cursor из ответа в cursor с теми же фильтрами
 // @Tags cat
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} []entities.CatWithPrimePhoto
+// @Param age_min query int false "Минимальный возраст"
+// @Param age_max query int false "Максимальный возраст"
+// @Param owner_id query int false "ID владельца"
+// @Param has_photo query bool false "Только коты с фото (true) или без фото (false)"
+// @Param created_from query string false "Начало периода создания (RFC 3339)"
+// @Param created_to query string false "Конец периода создания (RFC 3339)"
+// @Param sort query string false "Поле сортировки: created_at (по умолчанию), name или age"
+// @Param order query string false "Порядок сортировки: asc или desc"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Количество котов (по умолчанию 50, максимум 200)"
+// @Success 200 {object} entities.CatListResponse
+// @Failure 400 {object} entities.ErrorResponse
 // @Failure 401 {object} entities.ErrorResponse
 // @Failure 500 {object} entities.ErrorResponse
 // @Router /auth/cat/all [get]
@@ -133,8 +148,82 @@ func (h *catHandlerImpl) GetAllCats(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
 	defer cancel()
 
-	// Получаем всех котов
-	cats, err := h.catService.GetAllCats(ctx)
+	// Парсим фильтры из параметров запроса
+	catListFilter := &entities.CatListFilter{}
+	if err := c.QueryParser(catListFilter); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// Получаем котов
+	catListResponse, err := h.catService.GetAllCats(ctx, catListFilter)
+	if err != nil {
+		// Неверные фильтры, сортировка или курсор
+		var validationErr *entities.ValidationError
+		if errors.As(err, &validationErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(catListResponse)
+}
+
+// GetMyCats
+// @Summary Получение своих котов
+// @Description Получение котов, созданных текущим пользователем, с главным фото и количеством фото
+// @Tags cat
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} []entities.CatWithPrimePhoto
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/me/cats [get]
+func (h *catHandlerImpl) GetMyCats(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем котов пользователя
+	cats, err := h.catService.GetUserCats(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(cats)
+}
+
+// GetUserCats
+// @Summary Получение котов пользователя
+// @Description Получение котов, созданных пользователем, с главным фото и количеством фото
+// @Tags cat
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} []entities.CatWithPrimePhoto
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/{id}/cats [get]
+func (h *catHandlerImpl) GetUserCats(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем ID пользователя из параметров
+	userID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Получаем котов пользователя
+	cats, err := h.catService.GetUserCats(ctx, userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -309,9 +398,10 @@ func (h *catHandlerImpl) DeleteCat(c *fiber.Ctx) error {
 	defer cancel()
 
 	catID := c.Locals("catID").(int)
+	userID := c.Locals("userID").(int)
 
 	// Удаляем кота
-	err := h.catService.DeleteCat(ctx, catID)
+	err := h.catService.DeleteCat(ctx, userID, catID, utils.GetClientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -52,9 +52,10 @@ func (h *catPhotoHandlerImpl) AddCatPhotos(c *fiber.Ctx) error {
 	}
 
 	catID := c.Locals("catID").(int)
+	userID := c.Locals("userID").(int)
 
 	// Загружаем фото
-	catPhotoUploadResponse := h.catPhotoService.AddCatPhoto(ctx, catID, files)
+	catPhotoUploadResponse := h.catPhotoService.AddCatPhoto(ctx, userID, catID, files, utils.GetClientInfo(c))
 
 	// Отправляем результат
 	if catPhotoUploadResponse.UploadedCount > 0 {
@@ -124,9 +125,10 @@ func (h *catPhotoHandlerImpl) SetCatPhotoPrimary(c *fiber.Ctx) error {
 	}
 
 	catID := c.Locals("catID").(int)
+	userID := c.Locals("userID").(int)
 
 	// Устанавливаем главное фото
-	res, err := h.catPhotoService.SetCatPhotoPrimary(ctx, catID, photoID)
+	res, err := h.catPhotoService.SetCatPhotoPrimary(ctx, userID, catID, photoID, utils.GetClientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -161,9 +163,10 @@ func (h *catPhotoHandlerImpl) DeleteCatPhoto(c *fiber.Ctx) error {
 	}
 
 	catID := c.Locals("catID").(int)
+	userID := c.Locals("userID").(int)
 
 	// Удаляем фото
-	err = h.catPhotoService.DeleteCatPhoto(ctx, catID, photoID)
+	err = h.catPhotoService.DeleteCatPhoto(ctx, userID, catID, photoID, utils.GetClientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type DataExportHandler interface {
+	StartDataExport(c *fiber.Ctx) error
+	GetDataExport(c *fiber.Ctx) error
+	GetDataExportDownload(c *fiber.Ctx) error
+}
+
+type dataExportHandlerImpl struct {
+	dataExportService services.DataExportService
+	requestTimeout    time.Duration
+}
+
+func NewDataExportHandler(dataExportService services.DataExportService, requestTimeout time.Duration) DataExportHandler {
+	return &dataExportHandlerImpl{dataExportService: dataExportService, requestTimeout: requestTimeout}
+}
+
+// StartDataExport
+// @Summary Запуск выгрузки данных пользователя
+// @Description Запускает сборку ZIP архива с данными пользователя: профиль, созданные котики, метаданные и оригиналы фото.
+// @Description Архив собирается в фоне, статус возвращает GET /auth/user/export. Новая выгрузка заменяет предыдущую
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} entities.DataExport
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 409 {object} entities.ErrorResponse
+// @Failure 429 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/export [post]
+func (h *dataExportHandlerImpl) StartDataExport(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Запускаем выгрузку
+	dataExport, err := h.dataExportService.StartDataExport(ctx, userID, utils.GetClientInfo(c))
+	if err != nil {
+		if errors.Is(err, entities.ErrDataExportInProgress) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(dataExport)
+}
+
+// GetDataExport
+// @Summary Статус выгрузки данных пользователя
+// @Description Возвращает последнюю выгрузку пользователя: pending, processing, ready или failed
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.DataExport
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/export [get]
+func (h *dataExportHandlerImpl) GetDataExport(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем выгрузку
+	dataExport, err := h.dataExportService.GetDataExport(ctx, userID)
+	if err != nil {
+		if errors.Is(err, entities.ErrDataExportNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dataExport)
+}
+
+// GetDataExportDownload
+// @Summary Ссылка на скачивание выгрузки данных
+// @Description Возвращает временную ссылку (15 минут) на скачивание собранного архива выгрузки
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.DataExportDownloadResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Failure 409 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/export/download [get]
+func (h *dataExportHandlerImpl) GetDataExportDownload(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем ссылку на архив
+	dataExportDownloadResponse, err := h.dataExportService.GetDataExportDownload(ctx, userID)
+	if err != nil {
+		if errors.Is(err, entities.ErrDataExportNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, entities.ErrDataExportNotReady) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dataExportDownloadResponse)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+)
+
+type EmailHandler interface {
+	GetUserEmail(c *fiber.Ctx) error
+	UpdateUserEmail(c *fiber.Ctx) error
+	SendVerificationEmail(c *fiber.Ctx) error
+	ConfirmEmailVerification(c *fiber.Ctx) error
+}
+
+type emailHandlerImpl struct {
+	emailService   services.EmailService
+	requestTimeout time.Duration
+}
+
+func NewEmailHandler(emailService services.EmailService, requestTimeout time.Duration) EmailHandler {
+	return &emailHandlerImpl{emailService: emailService, requestTimeout: requestTimeout}
+}
+
+// GetUserEmail
+// @Summary Получение email пользователя
+// @Description Возвращает email текущего пользователя и статус его подтверждения
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.UserEmail
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/email [get]
+func (h *emailHandlerImpl) GetUserEmail(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем email пользователя
+	userEmail, err := h.emailService.GetUserEmail(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(userEmail)
+}
+
+// UpdateUserEmail
+// @Summary Изменение email пользователя
+// @Description Устанавливает новый email (пустая строка - удаление email) и отправляет письмо для его подтверждения
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param email body entities.UserUpdateEmailRequest true "Новый email"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/email [patch]
+func (h *emailHandlerImpl) UpdateUserEmail(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	userUpdateEmailRequest := &entities.UserUpdateEmailRequest{}
+	if err := c.BodyParser(&userUpdateEmailRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Обновляем email и отправляем письмо для подтверждения
+	err := h.emailService.UpdateUserEmail(ctx, userID, userUpdateEmailRequest)
+	if err != nil {
+		// Неверный формат email
+		if errors.Is(err, entities.ErrInvalidEmail) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully updated email")
+}
+
+// SendVerificationEmail
+// @Summary Повторная отправка письма для подтверждения email
+// @Description Отправляет письмо с одноразовой ссылкой для подтверждения email, предыдущая ссылка перестает действовать
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} string
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/email/verify [post]
+func (h *emailHandlerImpl) SendVerificationEmail(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Отправляем письмо
+	err := h.emailService.SendVerificationEmail(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusAccepted).SendString("Verification email sent")
+}
+
+// ConfirmEmailVerification
+// @Summary Подтверждение email
+// @Description Подтверждает email пользователя по одноразовому токену из письма
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body entities.EmailVerificationConfirmRequest true "Токен из письма"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 409 {object} entities.ErrorResponse
+// @Router /email/verify/confirm [post]
+func (h *emailHandlerImpl) ConfirmEmailVerification(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	emailVerificationConfirmRequest := &entities.EmailVerificationConfirmRequest{}
+	if err := c.BodyParser(&emailVerificationConfirmRequest); err != nil || emailVerificationConfirmRequest.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// Подтверждаем email
+	err := h.emailService.ConfirmEmailVerification(ctx, emailVerificationConfirmRequest)
+	if errors.Is(err, entities.ErrEmailTaken) {
+		// Email уже подтвержден другим пользователем
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": entities.ErrEmailTaken.Error()})
+	} else if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully verified email")
+}
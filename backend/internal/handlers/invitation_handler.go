@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type InvitationHandler interface {
+	CreateInvitation(c *fiber.Ctx) error
+	GetAllInvitations(c *fiber.Ctx) error
+	DeleteInvitation(c *fiber.Ctx) error
+}
+
+type invitationHandlerImpl struct {
+	invitationService services.InvitationService
+	requestTimeout    time.Duration
+}
+
+func NewInvitationHandler(invitationService services.InvitationService, requestTimeout time.Duration) InvitationHandler {
+	return &invitationHandlerImpl{invitationService: invitationService, requestTimeout: requestTimeout}
+}
+
+// CreateInvitation
+// @Summary Создание кода приглашения
+// @Description Создает код приглашения для регистрации с лимитом использований (по умолчанию 1) и сроком действия (по умолчанию 7 дней).
+// @Description Роль для зарегистрированных по коду пользователей может назначить только администратор. Код возвращается один раз
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param invitation body entities.InvitationCreateRequest true "Данные приглашения"
+// @Success 201 {object} entities.InvitationCreateResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Router /auth/invitation/create [post]
+func (h *invitationHandlerImpl) CreateInvitation(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	invitationCreateRequest := &entities.InvitationCreateRequest{}
+	if err := c.BodyParser(&invitationCreateRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := c.Locals("userID").(int)
+	role, _ := c.Locals("role").(string)
+
+	// Создаем приглашение
+	invitationCreateResponse, err := h.invitationService.CreateInvitation(ctx, userID, role, invitationCreateRequest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(invitationCreateResponse)
+}
+
+// GetAllInvitations
+// @Summary Получение кодов приглашений
+// @Description Возвращает все приглашения, созданные пользователем (без самих кодов), с количеством использований
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} []entities.Invitation
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/invitation/all [get]
+func (h *invitationHandlerImpl) GetAllInvitations(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем приглашения
+	invitations, err := h.invitationService.GetAllInvitations(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(invitations)
+}
+
+// DeleteInvitation
+// @Summary Удаление кода приглашения
+// @Description Удаляет приглашение пользователя, код сразу перестает действовать
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Router /auth/invitation/{id} [delete]
+func (h *invitationHandlerImpl) DeleteInvitation(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	invitationID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Удаляем приглашение
+	err = h.invitationService.DeleteInvitation(ctx, userID, invitationID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully deleted invitation")
+}
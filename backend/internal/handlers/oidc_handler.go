@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type OIDCHandler interface {
+	Login(c *fiber.Ctx) error
+	Callback(c *fiber.Ctx) error
+	LinkIdentity(c *fiber.Ctx) error
+	GetAllIdentities(c *fiber.Ctx) error
+	DeleteIdentity(c *fiber.Ctx) error
+}
+
+type oidcHandlerImpl struct {
+	oidcService    services.OIDCService
+	authService    services.AuthService
+	cookieOptions  *utils.TokenCookieOptions
+	stateLifetime  time.Duration
+	secureCookie   bool
+	requestTimeout time.Duration
+}
+
+func NewOIDCHandler(oidcService services.OIDCService, authService services.AuthService, cookieOptions *utils.TokenCookieOptions, stateLifetime time.Duration, secureCookie bool, requestTimeout time.Duration) OIDCHandler {
+	return &oidcHandlerImpl{
+		oidcService:    oidcService,
+		authService:    authService,
+		cookieOptions:  cookieOptions,
+		stateLifetime:  stateLifetime,
+		secureCookie:   secureCookie,
+		requestTimeout: requestTimeout,
+	}
+}
+
+// Login
+// @Summary Вход через OIDC провайдера
+// @Description Перенаправляет на страницу входа провайдера (authorization code flow с PKCE). После входа провайдер вернет пользователя на /oidc/callback
+// @Tags oidc
+// @Success 302
+// @Failure 404 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /oidc/login [get]
+func (h *oidcHandlerImpl) Login(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Формируем ссылку на страницу входа провайдера
+	authorizationResponse, state, err := h.oidcService.StartLogin(ctx, 0)
+	if err != nil {
+		if errors.Is(err, entities.ErrOIDCDisabled) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Запоминаем state в браузере пользователя
+	utils.SetOIDCStateCookie(c, state, h.stateLifetime, h.secureCookie)
+
+	return c.Redirect(authorizationResponse.AuthorizationURL, fiber.StatusFound)
+}
+
+// Callback
+// @Summary Завершение входа через OIDC провайдера
+// @Description Адрес возврата от провайдера. Проверяет state, обменивает код на токены, проверяет ID токен и возвращает access и refresh токены
+// @Description (в режиме cookie - устанавливает HttpOnly cookie). Если у пользователя включена 2FA, вместо токенов возвращается challenge_token для /login/2fa
+// @Tags oidc
+// @Produce json
+// @Param code query string true "Код авторизации"
+// @Param state query string true "State"
+// @Success 200 {object} entities.AuthResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Router /oidc/callback [get]
+func (h *oidcHandlerImpl) Callback(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим параметры запроса
+	callbackRequest := &entities.OIDCCallbackRequest{}
+	if err := c.QueryParser(callbackRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	// State одноразовый, cookie больше не нужна
+	stateCookie := c.Cookies(utils.OIDCStateCookie)
+	utils.ClearOIDCStateCookie(c, h.secureCookie)
+
+	// Завершаем вход
+	authResponse, err := h.authService.LoginUserOIDC(ctx, callbackRequest, stateCookie, utils.GetClientInfo(c))
+	if err != nil {
+		if errors.Is(err, entities.ErrOIDCDisabled) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, entities.ErrRegistrationClosed) || errors.Is(err, entities.ErrAccountDeleted) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// В режиме cookie токены передаются только в HttpOnly cookie
+	if h.cookieOptions != nil && authResponse.TokenPair != nil {
+		utils.SetTokenCookies(c, authResponse.TokenPair, h.cookieOptions)
+		authResponse.TokenPair = nil
+	}
+
+	return c.Status(fiber.StatusOK).JSON(authResponse)
+}
+
+// LinkIdentity
+// @Summary Привязка аккаунта OIDC провайдера
+// @Description Возвращает ссылку на страницу входа провайдера. После входа аккаунт провайдера привязывается к текущему пользователю
+// @Tags oidc
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.OIDCAuthorizationResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/oidc/link [post]
+func (h *oidcHandlerImpl) LinkIdentity(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Формируем ссылку на страницу входа провайдера
+	authorizationResponse, state, err := h.oidcService.StartLogin(ctx, userID)
+	if err != nil {
+		if errors.Is(err, entities.ErrOIDCDisabled) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Запоминаем state в браузере пользователя
+	utils.SetOIDCStateCookie(c, state, h.stateLifetime, h.secureCookie)
+
+	return c.Status(fiber.StatusOK).JSON(authorizationResponse)
+}
+
+// GetAllIdentities
+// @Summary Получение привязанных аккаунтов
+// @Description Возвращает аккаунты OIDC провайдеров, привязанные к пользователю
+// @Tags oidc
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} []entities.UserIdentity
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/oidc/identities [get]
+func (h *oidcHandlerImpl) GetAllIdentities(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем привязанные аккаунты
+	identities, err := h.oidcService.GetAllIdentities(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(identities)
+}
+
+// DeleteIdentity
+// @Summary Отвязка аккаунта OIDC провайдера
+// @Description Удаляет привязку аккаунта провайдера, вход через него в этот аккаунт больше невозможен
+// @Tags oidc
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Identity ID"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Router /auth/oidc/identities/{id} [delete]
+func (h *oidcHandlerImpl) DeleteIdentity(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	identityID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Удаляем привязку
+	err = h.oidcService.DeleteIdentity(ctx, userID, identityID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully deleted identity")
+}
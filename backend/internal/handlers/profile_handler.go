@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type ProfileHandler interface {
+	GetMyProfile(c *fiber.Ctx) error
+	GetUserProfile(c *fiber.Ctx) error
+	UpdateProfile(c *fiber.Ctx) error
+	UploadAvatar(c *fiber.Ctx) error
+	DeleteAvatar(c *fiber.Ctx) error
+}
+
+type profileHandlerImpl struct {
+	profileService     services.ProfileService
+	requestTimeout     time.Duration
+	fileRequestTimeout time.Duration
+}
+
+func NewProfileHandler(profileService services.ProfileService, requestTimeout, fileRequestTimeout time.Duration) ProfileHandler {
+	return &profileHandlerImpl{profileService: profileService, requestTimeout: requestTimeout, fileRequestTimeout: fileRequestTimeout}
+}
+
+// GetMyProfile
+// @Summary Получение своего профиля
+// @Description Возвращает профиль текущего пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.UserProfile
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Router /auth/user/profile [get]
+func (h *profileHandlerImpl) GetMyProfile(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Получаем профиль
+	userProfile, err := h.profileService.GetUserProfile(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(userProfile)
+}
+
+// GetUserProfile
+// @Summary Получение публичного профиля пользователя
+// @Description Возвращает публичный профиль пользователя по ID. Профили пользователей, помеченных на удаление, не возвращаются
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} entities.UserProfile
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 404 {object} entities.ErrorResponse
+// @Router /auth/user/{id}/profile [get]
+func (h *profileHandlerImpl) GetUserProfile(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Получаем id из параметров
+	userID, err := utils.ValidateIntParams(c, "id", 1, 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Получаем профиль
+	userProfile, err := h.profileService.GetUserProfile(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(userProfile)
+}
+
+// UpdateProfile
+// @Summary Изменение своего профиля
+// @Description Изменяет отображаемое имя (до 64 символов), описание (до 500) и местоположение (до 100).
+// @Description Поля, не переданные в запросе, не изменяются, пустая строка очищает поле
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param profile body entities.UserProfileUpdateRequest true "Поля профиля"
+// @Success 200 {object} entities.UserProfile
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Router /auth/user/profile [patch]
+func (h *profileHandlerImpl) UpdateProfile(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	profileUpdateRequest := &entities.UserProfileUpdateRequest{}
+	if err := c.BodyParser(&profileUpdateRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Обновляем профиль
+	userProfile, err := h.profileService.UpdateUserProfile(ctx, userID, profileUpdateRequest, utils.GetClientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(userProfile)
+}
+
+// UploadAvatar
+// @Summary Загрузка аватара
+// @Description Загружает аватар пользователя (jpg, png или webp до 5 МБ), предыдущий аватар удаляется
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param avatar formData file true "Файл изображения"
+// @Success 200 {object} entities.AvatarUploadResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 429 {object} entities.ErrorResponse
+// @Router /auth/user/avatar [put]
+func (h *profileHandlerImpl) UploadAvatar(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.fileRequestTimeout)
+	defer cancel()
+
+	// Получаем файл из multipart/formData
+	files, err := utils.GetFilesFromFormData(c, "avatar", 1)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Загружаем аватар
+	avatarUploadResponse, err := h.profileService.UploadAvatar(ctx, userID, files[0], utils.GetClientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(avatarUploadResponse)
+}
+
+// DeleteAvatar
+// @Summary Удаление аватара
+// @Description Удаляет аватар пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} string
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/user/avatar [delete]
+func (h *profileHandlerImpl) DeleteAvatar(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Удаляем аватар
+	err := h.profileService.DeleteAvatar(ctx, userID, utils.GetClientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully deleted avatar")
+}
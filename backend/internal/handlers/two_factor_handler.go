@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
+)
+
+type TwoFactorHandler interface {
+	EnrollTwoFactor(c *fiber.Ctx) error
+	ConfirmTwoFactor(c *fiber.Ctx) error
+	DisableTwoFactor(c *fiber.Ctx) error
+}
+
+type twoFactorHandlerImpl struct {
+	twoFactorService services.TwoFactorService
+	requestTimeout   time.Duration
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService, requestTimeout time.Duration) TwoFactorHandler {
+	return &twoFactorHandlerImpl{twoFactorService: twoFactorService, requestTimeout: requestTimeout}
+}
+
+// EnrollTwoFactor
+// @Summary Начало подключения 2FA
+// @Description Генерирует TOTP секрет и ссылку otpauth:// для приложения-аутентификатора. 2FA включается только после подтверждения кодом
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.TwoFactorEnrollResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 500 {object} entities.ErrorResponse
+// @Router /auth/2fa/enroll [post]
+func (h *twoFactorHandlerImpl) EnrollTwoFactor(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	userID := c.Locals("userID").(int)
+
+	// Генерируем секрет
+	twoFactorEnrollResponse, err := h.twoFactorService.EnrollTwoFactor(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(twoFactorEnrollResponse)
+}
+
+// ConfirmTwoFactor
+// @Summary Подтверждение подключения 2FA
+// @Description Включает 2FA после проверки кода из приложения и возвращает одноразовые коды восстановления (показываются один раз)
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param code body entities.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} entities.TwoFactorConfirmResponse
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Router /auth/2fa/confirm [post]
+func (h *twoFactorHandlerImpl) ConfirmTwoFactor(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	twoFactorCodeRequest := &entities.TwoFactorCodeRequest{}
+	if err := c.BodyParser(&twoFactorCodeRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Включаем 2FA
+	twoFactorConfirmResponse, err := h.twoFactorService.ConfirmTwoFactor(ctx, userID, twoFactorCodeRequest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(twoFactorConfirmResponse)
+}
+
+// DisableTwoFactor
+// @Summary Отключение 2FA
+// @Description Отключает 2FA, требует код из приложения или код восстановления.
+// @Description После нескольких неверных кодов подряд проверка кодов временно блокируется
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param code body entities.TwoFactorCodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} string
+// @Failure 400 {object} entities.ErrorResponse
+// @Failure 401 {object} entities.ErrorResponse
+// @Failure 429 {object} entities.ErrorResponse
+// @Router /auth/2fa [delete]
+func (h *twoFactorHandlerImpl) DisableTwoFactor(c *fiber.Ctx) error {
+
+	// Ограничение времени выполнения
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	// Парсим тело запроса в структуру
+	twoFactorCodeRequest := &entities.TwoFactorCodeRequest{}
+	if err := c.BodyParser(&twoFactorCodeRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	// Выключаем 2FA
+	err := h.twoFactorService.DisableTwoFactor(ctx, userID, twoFactorCodeRequest)
+	if err != nil {
+		// Проверка кодов временно заблокирована после неудачных попыток
+		var lockErr *entities.LoginLockedError
+		if errors.As(err, &lockErr) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(lockErr.RetryAfterSeconds()))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).SendString("Successfully disabled two-factor authentication")
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"github.com/unwelcome/iqjtest/pkg/utils"
 	"time"
 
@@ -13,7 +14,6 @@ import (
 type UserHandler interface {
 	GetUserByID(c *fiber.Ctx) error
 	GetAllUsers(c *fiber.Ctx) error
-	UpdateUserPassword(c *fiber.Ctx) error
 }
 
 type userHandlerImpl struct {
@@ -61,13 +61,21 @@ func (h *userHandlerImpl) GetUserByID(c *fiber.Ctx) error {
 
 // GetAllUsers
 // @Summary получение всех пользователей
-// @Description получение всех пользователей
+// @Description получение пользователей постранично, отсортированных по дате регистрации. Поиск по логину без учета регистра.
+// @Description Для следующей страницы передайте next_cursor из ответа в cursor. Только для администраторов
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} []entities.UserGet
+// @Param search query string false "Поиск по логину"
+// @Param search_mode query string false "Способ поиска: prefix (по началу логина, по умолчанию) или contains"
+// @Param order query string false "Порядок сортировки по дате регистрации: desc (по умолчанию) или asc"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Количество пользователей (по умолчанию 50, максимум 200)"
+// @Success 200 {object} entities.UserListResponse
+// @Failure 400 {object} entities.ErrorResponse
 // @Failure 401 {object} entities.ErrorResponse
+// @Failure 403 {object} entities.ErrorResponse
 // @Failure 500 {object} entities.ErrorResponse
 // @Router /auth/user/all [get]
 func (h *userHandlerImpl) GetAllUsers(c *fiber.Ctx) error {
@@ -76,47 +84,23 @@ func (h *userHandlerImpl) GetAllUsers(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
 	defer cancel()
 
-	// Получаем всех пользователей
-	users, err := h.userService.GetAllUsers(ctx)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	return c.Status(fiber.StatusOK).JSON(users)
-}
-
-// UpdateUserPassword
-// @Summary обновление пароля пользователя
-// @Description обновление пароля пользователя
-// @Tags users
-// @Accept json
-// @Produce json
-// @Security ApiKeyAuth
-// @Param user body entities.UserUpdatePasswordRequest true "Данные пользователя"
-// @Success 200 {object} entities.UserUpdatePasswordResponse
-// @Failure 400 {object} entities.ErrorResponse
-// @Failure 401 {object} entities.ErrorResponse
-// @Failure 500 {object} entities.ErrorResponse
-// @Router /auth/user/password [patch]
-func (h *userHandlerImpl) UpdateUserPassword(c *fiber.Ctx) error {
-
-	// Ограничение времени выполнения
-	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
-	defer cancel()
-
-	// Парсим тело запроса в структуру
-	userUpdatePasswordRequest := &entities.UserUpdatePasswordRequest{}
-	if err := c.BodyParser(&userUpdatePasswordRequest); err != nil {
+	// Парсим фильтры из параметров запроса
+	userListFilter := &entities.UserListFilter{}
+	if err := c.QueryParser(userListFilter); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
-	userID := c.Locals("userID").(int)
-
-	// Обновляем пароль пользователя
-	err := h.userService.UpdateUserPassword(ctx, userID, userUpdatePasswordRequest)
+	// Получаем пользователей
+	userListResponse, err := h.userService.GetAllUsers(ctx, userListFilter)
 	if err != nil {
+		// Неверные параметры выборки или курсор
+		var validationErr *entities.ValidationError
+		if errors.As(err, &validationErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(&entities.UserUpdatePasswordResponse{ID: userID})
+	return c.Status(fiber.StatusOK).JSON(userListResponse)
 }
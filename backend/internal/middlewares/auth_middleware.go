@@ -1,42 +1,60 @@
 package middlewares
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/services"
 	"github.com/unwelcome/iqjtest/pkg/utils"
 )
 
-func AuthMiddleware(secretKey string) fiber.Handler {
+func AuthMiddleware(authService services.AuthService, apiKeyService services.APIKeyService, cookieOptions *utils.TokenCookieOptions, middlewareRequestTimeout time.Duration) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
-		// Получаем заголовок авторизации
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "authorization header required"})
-		}
+		// Ограничение времени выполнения
+		ctx, cancel := context.WithTimeout(context.Background(), middlewareRequestTimeout)
+		defer cancel()
 
-		// Проверяем корректность заголовка
-		if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid authorization header format"})
-		}
+		// Авторизация по API ключу: доступны только запросы, разрешенные scopes ключа
+		if key, ok := utils.GetAPIKey(c); ok {
+			apiKey, err := apiKeyService.VerifyAPIKey(ctx, key)
+			if errors.Is(err, entities.ErrInvalidAPIKey) || errors.Is(err, entities.ErrAPIKeyExpired) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+			} else if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+
+			// Устанавливаем userID и scopes ключа в контекст
+			c.Locals("userID", apiKey.UserID)
+			c.Locals("scopes", apiKey.Scopes)
 
-		// Получаем токен из заголовка
-		accessToken := authHeader[7:]
+			return c.Next()
+		}
 
-		// Парсим токен
-		tokenClaims, err := utils.ParseToken(accessToken, secretKey)
+		// Получаем токен из cookie или заголовка авторизации
+		accessToken, err := utils.GetAccessToken(c, cookieOptions)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": fmt.Errorf("parse token error: %w", err).Error()})
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		// Проверяем тип токена
-		if tokenClaims.Type != entities.AccessTokenType {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token type"})
+		// Парсим токен и проверяем, что он не отозван
+		tokenClaims, err := authService.VerifyAccessToken(ctx, accessToken)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		// Устанавливаем userID в контекст
+		// Устанавливаем userID, роль и данные токена в контекст
 		c.Locals("userID", tokenClaims.UserID)
+		c.Locals("role", tokenClaims.Role)
+		c.Locals("tokenClaims", tokenClaims)
+
+		// Токен имперсонации: запоминаем администратора, действующего от имени пользователя
+		if tokenClaims.Actor != nil {
+			c.Locals("actorID", tokenClaims.Actor.UserID)
+		}
 
 		return c.Next()
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
 	"github.com/unwelcome/iqjtest/internal/services"
 )
 
@@ -22,14 +23,15 @@ func CatOwnershipMiddleware(catService services.CatService, middlewareRequestTim
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		// Получаем userID
+		// Получаем userID и роль
 		userID := c.Locals("userID").(int)
+		role, _ := c.Locals("role").(string)
 
-		// Проверяем права на изменения кота
+		// Проверяем права на изменения кота (администратор может изменять любого кота)
 		hasRights, err := catService.CheckOwnershipRight(ctx, userID, catID)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-		} else if !hasRights {
+		} else if !hasRights && !entities.HasRole(role, entities.RoleAdmin) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not enough right for this operation"})
 		}
 
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+// Защита от CSRF по схеме double-submit: значение заголовка должно совпадать со значением cookie
+
+func CSRFMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		// Безопасные методы не изменяют состояние
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+
+		// Запросы без cookie с токенами не подвержены CSRF
+		if c.Cookies(utils.AccessTokenCookie) == "" && c.Cookies(utils.RefreshTokenCookie) == "" {
+			return c.Next()
+		}
+
+		// Сравниваем CSRF токен из cookie и заголовка
+		csrfCookie := c.Cookies(utils.CSRFTokenCookie)
+		csrfHeader := c.Get(utils.CSRFTokenHeader)
+		if csrfCookie == "" || subtle.ConstantTimeCompare([]byte(csrfCookie), []byte(csrfHeader)) != 1 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "invalid csrf token"})
+		}
+
+		return c.Next()
+	}
+}
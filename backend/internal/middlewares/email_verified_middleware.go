@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/services"
+)
+
+func EmailVerifiedMiddleware(emailService services.EmailService, required bool, middlewareRequestTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		// Проверка отключена в конфиге
+		if !required {
+			return c.Next()
+		}
+
+		// Ограничение времени выполнения
+		ctx, cancel := context.WithTimeout(context.Background(), middlewareRequestTimeout)
+		defer cancel()
+
+		// Получаем userID
+		userID := c.Locals("userID").(int)
+
+		// Проверяем, что email пользователя подтвержден
+		verified, err := emailService.CheckEmailVerified(ctx, userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		} else if !verified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "email not verified"})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,15 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Запрет действий с аккаунтом по токену имперсонации (устанавливается после AuthMiddleware)
+
+func ForbidImpersonation(c *fiber.Ctx) error {
+	if _, impersonated := c.Locals("actorID").(int); impersonated {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not available with impersonation token"})
+	}
+
+	return c.Next()
+}
@@ -12,14 +12,23 @@ func LoggingRequest(l zerolog.Logger) fiber.Handler {
 
 		err := c.Next()
 
-		l.Info().
+		event := l.Info().
 			Str("ip", c.IP()).
 			Str("method", c.Method()).
 			Str("path", c.Path()).
 			//RawJSON("body", c.Body()).
 			Int("duration", int(time.Since(startTime).Milliseconds())).
-			Int("status", c.Response().StatusCode()).
-			Msg("request")
+			Int("status", c.Response().StatusCode())
+
+		// Пользователь запроса и администратор, если запрос выполнен по токену имперсонации
+		if userID, ok := c.Locals("userID").(int); ok {
+			event = event.Int("userID", userID)
+		}
+		if actorID, ok := c.Locals("actorID").(int); ok {
+			event = event.Int("actorID", actorID)
+		}
+
+		event.Msg("request")
 
 		return err
 	}
@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+)
+
+func RateLimitMiddleware(rateLimitRepository repositories.RateLimitRepository, middlewareRequestTimeout time.Duration) func(policy *entities.RateLimitPolicy) fiber.Handler {
+	return func(policy *entities.RateLimitPolicy) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+
+			// Ограничение времени выполнения
+			ctx, cancel := context.WithTimeout(context.Background(), middlewareRequestTimeout)
+			defer cancel()
+
+			// Авторизованных пользователей ограничиваем по userID, анонимных - по IP
+			subject := "ip:" + c.IP()
+			if userID, ok := c.Locals("userID").(int); ok {
+				subject = fmt.Sprintf("user:%d", userID)
+			}
+
+			// Учитываем запрос (при недоступности кеша запрос пропускается)
+			result, err := rateLimitRepository.Hit(ctx, policy, subject)
+			if err != nil {
+				return c.Next()
+			}
+
+			// Устанавливаем заголовки RateLimit-*
+			resetSeconds := strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds())))
+			c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			c.Set("RateLimit-Reset", resetSeconds)
+
+			// Лимит исчерпан
+			if !result.Allowed {
+				c.Set(fiber.HeaderRetryAfter, resetSeconds)
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many requests"})
+			}
+
+			return c.Next()
+		}
+	}
+}
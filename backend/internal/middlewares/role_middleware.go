@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// Проверка, что роль пользователя не ниже требуемой (устанавливается после AuthMiddleware)
+
+func RequireRole(requiredRole string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		// Получаем роль пользователя
+		role, _ := c.Locals("role").(string)
+
+		// Проверяем права
+		if !entities.HasRole(role, requiredRole) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not enough right for this operation"})
+		}
+
+		return c.Next()
+	}
+}
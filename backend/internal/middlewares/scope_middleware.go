@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Проверка scope API ключа. Запросы с access токеном имеют все права пользователя и проходят без проверки
+
+func RequireScope(requiredScope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		// Получаем scopes API ключа
+		scopes, isAPIKey := c.Locals("scopes").([]string)
+
+		// Проверяем права ключа
+		if isAPIKey && !slices.Contains(scopes, requiredScope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "api key has no " + requiredScope + " scope"})
+		}
+
+		return c.Next()
+	}
+}
+
+// Запрет доступа по API ключу (управление аккаунтом, сессиями и ключами доступно только по access токену)
+
+func RequireSession(c *fiber.Ctx) error {
+	if _, isAPIKey := c.Locals("scopes").([]string); isAPIKey {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not available with api key"})
+	}
+
+	return c.Next()
+}
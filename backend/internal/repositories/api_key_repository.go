@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, apiKey *entities.APIKey, keyHash string) error
+	GetAllAPIKeys(ctx context.Context, userID int) ([]*entities.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*entities.APIKey, error)
+	UpdateAPIKeyLastUsed(ctx context.Context, apiKeyID int) error
+	DeleteAPIKey(ctx context.Context, userID, apiKeyID int) error
+}
+
+type apiKeyRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepositoryImpl{db: db}
+}
+
+func (r *apiKeyRepositoryImpl) CreateAPIKey(ctx context.Context, apiKey *entities.APIKey, keyHash string) error {
+	query := `INSERT INTO api_keys(user_id, name, key_prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, apiKey.UserID, apiKey.Name, apiKey.Prefix, keyHash, pq.Array(apiKey.Scopes), apiKey.ExpiresAt).Scan(&apiKey.ID, &apiKey.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepositoryImpl) GetAllAPIKeys(ctx context.Context, userID int) ([]*entities.APIKey, error) {
+	query := `SELECT id, name, key_prefix, scopes, expires_at, last_used_at, created_at FROM api_keys WHERE user_id = $1 ORDER BY id`
+
+	// Получаем все ключи пользователя
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []*entities.APIKey{}
+
+	// Меппим каждый ключ в структуру
+	for rows.Next() {
+		apiKey := &entities.APIKey{UserID: userID}
+		err = rows.Scan(&apiKey.ID, &apiKey.Name, &apiKey.Prefix, pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		// Добавляем в массив ключей
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+func (r *apiKeyRepositoryImpl) GetAPIKeyByHash(ctx context.Context, keyHash string) (*entities.APIKey, error) {
+	// Ключи пользователей, помеченных на удаление, не действуют
+	query := `SELECT k.id, k.user_id, k.name, k.key_prefix, k.scopes, k.expires_at, k.last_used_at, k.created_at FROM api_keys k JOIN users u ON u.id = k.user_id WHERE k.key_hash = $1 AND u.deletion_requested_at IS NULL`
+
+	// Меппинг запроса в структуру
+	apiKey := &entities.APIKey{}
+	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *apiKeyRepositoryImpl) UpdateAPIKeyLastUsed(ctx context.Context, apiKeyID int) error {
+	query := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, apiKeyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepositoryImpl) DeleteAPIKey(ctx context.Context, userID, apiKeyID int) error {
+	query := `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`
+
+	// Удаляем ключ
+	result, err := r.db.ExecContext(ctx, query, apiKeyID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем что ключ был удалён
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("api key not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type AuditRepository interface {
+	CreateAuditEvent(ctx context.Context, event *entities.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter *entities.AuditEventFilter) ([]*entities.AuditEvent, error)
+}
+
+type auditRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepositoryImpl{db: db}
+}
+
+func (r *auditRepositoryImpl) CreateAuditEvent(ctx context.Context, event *entities.AuditEvent) error {
+	query := `INSERT INTO audit_events(actor_id, impersonator_id, action, target_type, target_id, outcome, ip, user_agent, details) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`
+
+	// Анонимное действие (например, неудачный вход) сохраняем без actor_id, действие без имперсонации - без impersonator_id
+	actorID := sql.NullInt64{Int64: int64(event.ActorID), Valid: event.ActorID != 0}
+	impersonatorID := sql.NullInt64{Int64: int64(event.ImpersonatorID), Valid: event.ImpersonatorID != 0}
+
+	err := r.db.QueryRowContext(ctx, query, actorID, impersonatorID, event.Action, event.TargetType, event.TargetID, event.Outcome, event.IP, event.UserAgent, event.Details).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *auditRepositoryImpl) GetAuditEvents(ctx context.Context, filter *entities.AuditEventFilter) ([]*entities.AuditEvent, error) {
+
+	// Собираем условия по заданным фильтрам
+	conditions := []string{}
+	args := []any{}
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorID != 0 {
+		addCondition("actor_id = $%d", filter.ActorID)
+	}
+	if filter.ImpersonatorID != 0 {
+		addCondition("impersonator_id = $%d", filter.ImpersonatorID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		addCondition("target_id = $%d", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		addCondition("outcome = $%d", filter.Outcome)
+	}
+	if filter.IP != "" {
+		addCondition("ip = $%d", filter.IP)
+	}
+	if !filter.FromTime.IsZero() {
+		addCondition("created_at >= $%d", filter.FromTime)
+	}
+	if !filter.ToTime.IsZero() {
+		addCondition("created_at < $%d", filter.ToTime)
+	}
+	if filter.BeforeID != 0 {
+		addCondition("id < $%d", filter.BeforeID)
+	}
+
+	query := `SELECT id, actor_id, impersonator_id, action, target_type, target_id, outcome, ip, user_agent, details, created_at FROM audit_events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	// Получаем события, новые первыми
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*entities.AuditEvent{}
+
+	// Меппим каждое событие в структуру
+	for rows.Next() {
+		event := &entities.AuditEvent{}
+		var actorID, impersonatorID sql.NullInt64
+		err = rows.Scan(&event.ID, &actorID, &impersonatorID, &event.Action, &event.TargetType, &event.TargetID, &event.Outcome, &event.IP, &event.UserAgent, &event.Details, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		event.ActorID = int(actorID.Int64)
+		event.ImpersonatorID = int(impersonatorID.Int64)
+
+		// Добавляем в массив событий
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
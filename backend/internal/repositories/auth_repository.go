@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/unwelcome/iqjtest/pkg/utils"
 	"time"
@@ -16,12 +18,86 @@ type AuthRepository interface {
 	ReplaceToken(ctx context.Context, userID int, oldToken, newToken, tokenType string, expiresIn time.Duration) error
 	DeleteToken(ctx context.Context, userID int, token string, tokenType string) error
 	DeleteAllTokens(ctx context.Context, userID int) error
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresIn time.Duration) error
+	RevokeAllAccessTokens(ctx context.Context, userID int) error
+	CheckRevokedAccessToken(ctx context.Context, tokenID string) (bool, error)
+	SaveSession(ctx context.Context, userID int, session *entities.Session, refreshToken, familyID string, expiresIn time.Duration) error
+	GetSession(ctx context.Context, userID int, sessionID string) (*entities.Session, string, string, error)
+	GetAllSessions(ctx context.Context, userID int) ([]*entities.Session, error)
+	DeleteSession(ctx context.Context, userID int, sessionID string) error
+	RevokeSession(ctx context.Context, sessionID string, expiresIn time.Duration) error
+	CheckRevokedSession(ctx context.Context, sessionID string) (bool, error)
+	CreateTokenFamily(ctx context.Context, familyID, tokenID string, expiresIn time.Duration) error
+	RotateTokenFamily(ctx context.Context, userID int, familyID, oldTokenID, newTokenID, oldToken, newToken string, expiresIn time.Duration) (bool, error)
+	DeleteTokenFamily(ctx context.Context, familyID string) error
+	AddFailedLoginAttempt(ctx context.Context, scope, value string, window time.Duration) (int, error)
+	ResetFailedLoginAttempts(ctx context.Context, scope, value string) error
+	LockLogin(ctx context.Context, scope, value string, duration time.Duration) error
+	GetLoginLock(ctx context.Context, scope, value string) (time.Duration, error)
+	SaveOneTimeToken(ctx context.Context, purpose string, tokenHash string, token *entities.OneTimeToken, expiresIn time.Duration) error
+	GetOneTimeToken(ctx context.Context, purpose string, tokenHash string) (*entities.OneTimeToken, error)
+	ConsumeOneTimeToken(ctx context.Context, purpose string, tokenHash string) (*entities.OneTimeToken, error)
+	DeleteUserOneTimeToken(ctx context.Context, purpose string, userID int) error
+	SaveOIDCState(ctx context.Context, stateHash string, state *entities.OIDCState, expiresIn time.Duration) error
+	ConsumeOIDCState(ctx context.Context, stateHash string) (*entities.OIDCState, error)
 }
 
 type authRepositoryImpl struct {
 	redis *redis.Client
 }
 
+// Атомарная ротация токена в семействе вместе с заменой токена в сете refresh токенов пользователя.
+// Возвращает 1 если токен был текущим и заменен, 0 если токен уже был использован ранее,
+// -1 если семейство не найдено, -2 если токен отозван (его нет в сете). При -1 и -2 ничего не меняется
+var rotateTokenFamilyScript = redis.NewScript(`
+	local current = redis.call("GET", KEYS[1])
+	if not current then
+		return -1
+	end
+	if current ~= ARGV[1] then
+		if redis.call("SISMEMBER", KEYS[2], ARGV[1]) == 1 then
+			return 0
+		end
+		return -1
+	end
+	if redis.call("SISMEMBER", KEYS[3], ARGV[4]) == 0 then
+		return -2
+	end
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	redis.call("SADD", KEYS[2], ARGV[1])
+	redis.call("PEXPIRE", KEYS[2], ARGV[3])
+	redis.call("SREM", KEYS[3], ARGV[4])
+	redis.call("SADD", KEYS[3], ARGV[5])
+	redis.call("PEXPIRE", KEYS[3], ARGV[3])
+	return 1
+`)
+
+// Атомарный отзыв всех выданных access токенов пользователя: каждый ID из сета добавляется в список отозванных
+// на оставшееся время жизни сета, затем сет удаляется. Токен, выданный параллельно, не может потеряться между чтением и удалением
+var revokeAllAccessTokensScript = redis.NewScript(`
+	local ids = redis.call("SMEMBERS", KEYS[1])
+	if #ids == 0 then
+		return 0
+	end
+	local ttl = redis.call("PTTL", KEYS[1])
+	if ttl > 0 then
+		for _, id in ipairs(ids) do
+			redis.call("SET", ARGV[1] .. id, 1, "PX", ttl)
+		end
+	end
+	redis.call("DEL", KEYS[1])
+	return #ids
+`)
+
+// Сессия в том виде, в котором она хранится в кеше. Все сессии пользователя лежат в одном хеше,
+// поэтому у каждой записи свой срок жизни - срок жизни ее последнего refresh токена
+type sessionRecord struct {
+	*entities.Session
+	RefreshToken string    `json:"refresh_token"`
+	FamilyID     string    `json:"family_id"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
 func NewAuthRepository(redis *redis.Client) AuthRepository {
 	return &authRepositoryImpl{redis: redis}
 }
@@ -116,5 +192,394 @@ func (r *authRepositoryImpl) DeleteAllTokens(ctx context.Context, userID int) er
 		return fmt.Errorf("failed to delete all refresh tokens: %s", err.Error())
 	}
 
+	// Удаляем все сессии
+	err = r.redis.Del(ctx, utils.GetSessionsKey(userID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete all sessions: %s", err.Error())
+	}
+
 	return nil
 }
+
+func (r *authRepositoryImpl) RevokeAccessToken(ctx context.Context, tokenID string, expiresIn time.Duration) error {
+	// Токен уже истек, отзывать нечего
+	if expiresIn <= 0 {
+		return nil
+	}
+
+	// Добавляем ID токена в список отозванных до конца его жизни
+	err := r.redis.Set(ctx, utils.GetRevokedTokenKey(tokenID), 1, expiresIn).Err()
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) RevokeAllAccessTokens(ctx context.Context, userID int) error {
+	key := utils.GetTokenKey(userID, entities.AccessTokenType)
+
+	// TTL сета не меньше оставшегося времени жизни любого токена из него, поэтому отозванные ID хранятся до конца TTL сета
+	err := revokeAllAccessTokensScript.Run(ctx, r.redis, []string{key}, utils.GetRevokedTokenKey("")).Err()
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) CheckRevokedAccessToken(ctx context.Context, tokenID string) (bool, error) {
+	count, err := r.redis.Exists(ctx, utils.GetRevokedTokenKey(tokenID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("check revoked token failed: %s", err.Error())
+	}
+
+	return count > 0, nil
+}
+
+func (r *authRepositoryImpl) SaveSession(ctx context.Context, userID int, session *entities.Session, refreshToken, familyID string, expiresIn time.Duration) error {
+	key := utils.GetSessionsKey(userID)
+
+	// Сериализуем сессию вместе с текущим refresh токеном и его семейством
+	record := &sessionRecord{Session: session, RefreshToken: refreshToken, FamilyID: familyID, ExpiresAt: time.Now().Add(expiresIn)}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %s", err.Error())
+	}
+
+	// Создаем транзакцию для сохранения сессии
+	_, err = r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+
+		// Сохраняем сессию в хеш
+		pipe.HSet(ctx, key, session.ID, value)
+
+		// Обновляем TTL для всего хеша, истекшие записи удаляются при чтении сессий
+		pipe.Expire(ctx, key, expiresIn)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) GetSession(ctx context.Context, userID int, sessionID string) (*entities.Session, string, string, error) {
+	value, err := r.redis.HGet(ctx, utils.GetSessionsKey(userID), sessionID).Result()
+	if err == redis.Nil {
+		return nil, "", "", entities.ErrSessionNotFound
+	} else if err != nil {
+		return nil, "", "", fmt.Errorf("failed to get session: %s", err.Error())
+	}
+
+	// Десериализуем сессию
+	record := &sessionRecord{}
+	err = json.Unmarshal([]byte(value), record)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to unmarshal session: %s", err.Error())
+	}
+
+	// Refresh токен сессии истек, сессия завершена
+	if !record.ExpiresAt.After(time.Now()) {
+		return nil, "", "", entities.ErrSessionNotFound
+	}
+
+	return record.Session, record.RefreshToken, record.FamilyID, nil
+}
+
+func (r *authRepositoryImpl) GetAllSessions(ctx context.Context, userID int) ([]*entities.Session, error) {
+	key := utils.GetSessionsKey(userID)
+
+	values, err := r.redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %s", err.Error())
+	}
+
+	var sessions []*entities.Session
+	var expiredIDs []string
+
+	// Десериализуем каждую сессию, сессии с истекшим refresh токеном пропускаем
+	now := time.Now()
+	for sessionID, value := range values {
+		record := &sessionRecord{}
+		err = json.Unmarshal([]byte(value), record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session: %s", err.Error())
+		}
+		if !record.ExpiresAt.After(now) {
+			expiredIDs = append(expiredIDs, sessionID)
+			continue
+		}
+		sessions = append(sessions, record.Session)
+	}
+
+	// Удаляем истекшие сессии из хеша
+	if len(expiredIDs) > 0 {
+		err = r.redis.HDel(ctx, key, expiredIDs...).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete expired sessions: %s", err.Error())
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r *authRepositoryImpl) DeleteSession(ctx context.Context, userID int, sessionID string) error {
+	value, err := r.redis.HDel(ctx, utils.GetSessionsKey(userID), sessionID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %s", err.Error())
+	} else if value == 0 {
+		return entities.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) RevokeSession(ctx context.Context, sessionID string, expiresIn time.Duration) error {
+	// Все access токены сессии становятся недействительными до конца их жизни
+	err := r.redis.Set(ctx, utils.GetRevokedSessionKey(sessionID), 1, expiresIn).Err()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) CheckRevokedSession(ctx context.Context, sessionID string) (bool, error) {
+	count, err := r.redis.Exists(ctx, utils.GetRevokedSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("check revoked session failed: %s", err.Error())
+	}
+
+	return count > 0, nil
+}
+
+func (r *authRepositoryImpl) CreateTokenFamily(ctx context.Context, familyID, tokenID string, expiresIn time.Duration) error {
+	currentKey, _ := utils.GetTokenFamilyKeys(familyID)
+
+	// Запоминаем первый refresh токен семейства как текущий
+	err := r.redis.Set(ctx, currentKey, tokenID, expiresIn).Err()
+	if err != nil {
+		return fmt.Errorf("failed to create token family: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) RotateTokenFamily(ctx context.Context, userID int, familyID, oldTokenID, newTokenID, oldToken, newToken string, expiresIn time.Duration) (bool, error) {
+	currentKey, usedKey := utils.GetTokenFamilyKeys(familyID)
+	tokensKey := utils.GetTokenKey(userID, entities.RefreshTokenType)
+
+	// Заменяем текущий токен семейства и токен в сете, если предъявлен именно текущий и он не отозван
+	keys := []string{currentKey, usedKey, tokensKey}
+	result, err := rotateTokenFamilyScript.Run(ctx, r.redis, keys, oldTokenID, newTokenID, expiresIn.Milliseconds(), oldToken, newToken).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate token family: %s", err.Error())
+	}
+
+	switch result {
+	case 1:
+		return false, nil
+	case 0:
+		return true, nil
+	case -2:
+		return false, fmt.Errorf("%w: token not found", entities.ErrInvalidRefreshToken)
+	default:
+		return false, fmt.Errorf("%w: token family not found", entities.ErrInvalidRefreshToken)
+	}
+}
+
+func (r *authRepositoryImpl) DeleteTokenFamily(ctx context.Context, familyID string) error {
+	currentKey, usedKey := utils.GetTokenFamilyKeys(familyID)
+
+	err := r.redis.Del(ctx, currentKey, usedKey).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete token family: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) AddFailedLoginAttempt(ctx context.Context, scope, value string, window time.Duration) (int, error) {
+	attemptsKey, _ := utils.GetLoginAttemptsKeys(scope, value)
+
+	// Увеличиваем счетчик и продлеваем окно подсчета попыток
+	var incr *redis.IntCmd
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, attemptsKey)
+		pipe.Expire(ctx, attemptsKey, window)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add login attempt: %s", err.Error())
+	}
+
+	return int(incr.Val()), nil
+}
+
+func (r *authRepositoryImpl) ResetFailedLoginAttempts(ctx context.Context, scope, value string) error {
+	attemptsKey, _ := utils.GetLoginAttemptsKeys(scope, value)
+
+	err := r.redis.Del(ctx, attemptsKey).Err()
+	if err != nil {
+		return fmt.Errorf("failed to reset login attempts: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) LockLogin(ctx context.Context, scope, value string, duration time.Duration) error {
+	_, lockKey := utils.GetLoginAttemptsKeys(scope, value)
+
+	err := r.redis.Set(ctx, lockKey, 1, duration).Err()
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) GetLoginLock(ctx context.Context, scope, value string) (time.Duration, error) {
+	_, lockKey := utils.GetLoginAttemptsKeys(scope, value)
+
+	// Оставшееся время блокировки, отрицательное значение - блокировки нет
+	ttl, err := r.redis.PTTL(ctx, lockKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get login lock: %s", err.Error())
+	}
+
+	return ttl, nil
+}
+
+func (r *authRepositoryImpl) SaveOneTimeToken(ctx context.Context, purpose string, tokenHash string, token *entities.OneTimeToken, expiresIn time.Duration) error {
+	userKey := utils.GetUserOneTimeTokenKey(token.UserID, purpose)
+
+	// Сериализуем токен
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal one-time token: %s", err.Error())
+	}
+
+	// Получаем предыдущий токен пользователя, он перестанет действовать
+	previousHash, err := r.redis.Get(ctx, userKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to get previous token: %s", err.Error())
+	}
+
+	// Создаем транзакцию для замены токена
+	_, err = r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previousHash != "" {
+			pipe.Del(ctx, utils.GetOneTimeTokenKey(purpose, previousHash))
+		}
+		pipe.Set(ctx, utils.GetOneTimeTokenKey(purpose, tokenHash), data, expiresIn)
+		pipe.Set(ctx, userKey, tokenHash, expiresIn)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save one-time token: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) GetOneTimeToken(ctx context.Context, purpose string, tokenHash string) (*entities.OneTimeToken, error) {
+
+	// Получаем токен без его использования
+	data, err := r.redis.Get(ctx, utils.GetOneTimeTokenKey(purpose, tokenHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("token not found or expired")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get one-time token: %s", err.Error())
+	}
+
+	return unmarshalOneTimeToken(data)
+}
+
+func (r *authRepositoryImpl) ConsumeOneTimeToken(ctx context.Context, purpose string, tokenHash string) (*entities.OneTimeToken, error) {
+
+	// Получаем и сразу удаляем токен, повторно его использовать нельзя
+	data, err := r.redis.GetDel(ctx, utils.GetOneTimeTokenKey(purpose, tokenHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("token not found or expired")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to consume one-time token: %s", err.Error())
+	}
+
+	token, err := unmarshalOneTimeToken(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Удаляем ссылку на токен у пользователя (если не получилось - не критично)
+	_ = r.redis.Del(ctx, utils.GetUserOneTimeTokenKey(token.UserID, purpose)).Err()
+
+	return token, nil
+}
+
+func (r *authRepositoryImpl) DeleteUserOneTimeToken(ctx context.Context, purpose string, userID int) error {
+	userKey := utils.GetUserOneTimeTokenKey(userID, purpose)
+
+	// Получаем текущий токен пользователя
+	tokenHash, err := r.redis.Get(ctx, userKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get one-time token: %s", err.Error())
+	}
+
+	// Удаляем токен и ссылку на него
+	err = r.redis.Del(ctx, utils.GetOneTimeTokenKey(purpose, tokenHash), userKey).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete one-time token: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) SaveOIDCState(ctx context.Context, stateHash string, state *entities.OIDCState, expiresIn time.Duration) error {
+
+	// Сериализуем состояние
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal oidc state: %s", err.Error())
+	}
+
+	// Сохраняем состояние до возврата пользователя от провайдера
+	err = r.redis.Set(ctx, utils.GetOIDCStateKey(stateHash), data, expiresIn).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save oidc state: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *authRepositoryImpl) ConsumeOIDCState(ctx context.Context, stateHash string) (*entities.OIDCState, error) {
+
+	// Получаем и сразу удаляем состояние, повторно использовать его нельзя
+	data, err := r.redis.GetDel(ctx, utils.GetOIDCStateKey(stateHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("state not found or expired")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to consume oidc state: %s", err.Error())
+	}
+
+	// Десериализуем состояние
+	state := &entities.OIDCState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal oidc state: %s", err.Error())
+	}
+
+	return state, nil
+}
+
+// Десериализация одноразового токена
+
+func unmarshalOneTimeToken(data []byte) (*entities.OneTimeToken, error) {
+	token := &entities.OneTimeToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal one-time token: %s", err.Error())
+	}
+
+	return token, nil
+}
@@ -7,12 +7,15 @@ import (
 	"github.com/minio/minio-go/v7"
 	"github.com/unwelcome/iqjtest/internal/entities"
 	"github.com/unwelcome/iqjtest/pkg/utils"
+	"io"
 )
 
 type CatPhotoRepository interface {
 	AddCatPhoto(ctx context.Context, catID int, req *entities.CatPhotoUploadRequest) (*entities.CatPhotoUploadSuccess, error)
 	GetAllCatPhotos(ctx context.Context, catID int) ([]*entities.CatPhotoUrl, error)
 	GetCatPhotoByID(ctx context.Context, photoID int) (*entities.CatPhoto, error)
+	GetAllCatPhotosInfo(ctx context.Context, catID int) ([]*entities.CatPhoto, error)
+	GetCatPhotoObject(ctx context.Context, filename string) (io.ReadCloser, error)
 	SetCatPhotoPrimary(ctx context.Context, catID, photoID int) error
 	DeleteCatPhoto(ctx context.Context, photoID int) error
 	DeleteAllCatPhotos(ctx context.Context, catID int) error
@@ -105,6 +108,51 @@ func (r *catPhotoRepositoryImpl) GetCatPhotoByID(ctx context.Context, photoID in
 	return catPhoto, nil
 }
 
+func (r *catPhotoRepositoryImpl) GetAllCatPhotosInfo(ctx context.Context, catID int) ([]*entities.CatPhoto, error) {
+	query := `SELECT id, url, filename, filesize, mime_type, is_primary, created_at FROM cat_photos WHERE cat_id = $1 ORDER BY id ASC;`
+
+	// Выполняем запрос в бд
+	rows, err := r.db.QueryContext(ctx, query, catID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catPhotos := []*entities.CatPhoto{}
+
+	// Меппинг ответа в структуру
+	for rows.Next() {
+		catPhoto := &entities.CatPhoto{CatID: catID}
+		err = rows.Scan(&catPhoto.ID, &catPhoto.Url, &catPhoto.FileName, &catPhoto.FileSize, &catPhoto.MimeType, &catPhoto.IsPrimary, &catPhoto.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		catPhotos = append(catPhotos, catPhoto)
+	}
+
+	return catPhotos, rows.Err()
+}
+
+func (r *catPhotoRepositoryImpl) GetCatPhotoObject(ctx context.Context, filename string) (io.ReadCloser, error) {
+	// Получаем оригинал фото из minio
+	object, err := r.minioClient.GetObject(ctx, r.bucketName, filename, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject не обращается к minio до первого чтения, проверяем что объект существует
+	_, err = object.Stat()
+	if err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, entities.ErrPhotoFileNotFound
+		}
+		return nil, err
+	}
+
+	return object, nil
+}
+
 func (r *catPhotoRepositoryImpl) SetCatPhotoPrimary(ctx context.Context, catID, photoID int) error {
 	// Создаем транзакцию
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -165,10 +213,15 @@ func (r *catPhotoRepositoryImpl) DeleteCatPhoto(ctx context.Context, photoID int
 func (r *catPhotoRepositoryImpl) DeleteAllCatPhotos(ctx context.Context, catID int) error {
 	prefix := fmt.Sprintf("cat/%d/", catID)
 
+	// Контекст отменяется при выходе, чтобы горутина и удаление в minio не остались висеть после ошибки
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Создаем канал для записи объектов
 	objectCh := make(chan minio.ObjectInfo)
 
-	// Создаем горутину
+	// Создаем горутину, ошибка получения списка объектов возвращается после удаления уже найденных
+	var listErr error
 	go func() {
 		defer close(objectCh)
 
@@ -178,9 +231,14 @@ func (r *catPhotoRepositoryImpl) DeleteAllCatPhotos(ctx context.Context, catID i
 			Recursive: true,
 		}) {
 			if object.Err != nil {
-				continue
+				listErr = object.Err
+				return
+			}
+			select {
+			case objectCh <- object:
+			case <-ctx.Done():
+				return
 			}
-			objectCh <- object
 		}
 	}()
 
@@ -190,5 +248,10 @@ func (r *catPhotoRepositoryImpl) DeleteAllCatPhotos(ctx context.Context, catID i
 		return fmt.Errorf("failed to remove photo %s: %w", removeErr.ObjectName, removeErr.Err)
 	}
 
+	// Без полного списка объектов нельзя считать, что удалено все
+	if listErr != nil {
+		return fmt.Errorf("failed to list photos: %w", listErr)
+	}
+
 	return nil
 }
@@ -3,13 +3,19 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+
 	"github.com/unwelcome/iqjtest/internal/entities"
 )
 
 type CatRepository interface {
 	CreateCat(ctx context.Context, userID int, cat *entities.Cat) error
 	GetCatByID(ctx context.Context, catID int) (*entities.Cat, error)
-	GetAllCats(ctx context.Context) ([]*entities.CatWithPrimePhoto, error)
+	GetAllCats(ctx context.Context, filter *entities.CatListFilter) ([]*entities.CatWithPrimePhoto, error)
+	CountCats(ctx context.Context, filter *entities.CatListFilter) (int, error)
+	GetAllUserCats(ctx context.Context, userID int) ([]*entities.Cat, error)
+	GetCatsByOwner(ctx context.Context, userID int) ([]*entities.CatWithPrimePhoto, error)
 	UpdateCatName(ctx context.Context, catID int, newName string) error
 	UpdateCatAge(ctx context.Context, catID int, newAge int) error
 	UpdateCatDescription(ctx context.Context, catID int, newDescription string) error
@@ -51,30 +57,137 @@ func (r *catRepositoryImpl) GetCatByID(ctx context.Context, catID int) (*entitie
 	return cat, nil
 }
 
-func (r *catRepositoryImpl) GetAllCats(ctx context.Context) ([]*entities.CatWithPrimePhoto, error) {
-	// Запрос на получение всех котов с left join фото котов, сортируя по catID, затем по is_primary и в конце по photoID
-	// Т.о. Получаем кота с первым is_primary фото либо кота с первым фото либо кота без фото
-	query := `
-		SELECT DISTINCT ON (c.id)
-			c.id,
-			c.name,
-			c.age,
-			cp.id AS photo_id, 
-			cp.url
-		FROM cats c
-		LEFT JOIN cat_photos cp ON c.id = cp.cat_id
-		ORDER BY c.id, cp.is_primary DESC NULLS LAST, cp.id ASC;
-	`
+// Кот с главным фото (первое is_primary фото либо первое фото, если главное не выбрано) и количеством фото
+
+const catWithPrimePhotoQuery = `
+	SELECT
+		c.id,
+		c.name,
+		c.age,
+		COALESCE(c.created_by, 0),
+		c.created_at,
+		p.id AS photo_id,
+		p.url,
+		(SELECT COUNT(*) FROM cat_photos WHERE cat_id = c.id) AS photo_count
+	FROM cats c
+	LEFT JOIN LATERAL (
+		SELECT id, url FROM cat_photos WHERE cat_id = c.id ORDER BY is_primary DESC NULLS LAST, id ASC LIMIT 1
+	) p ON true`
+
+// Колонки сортировки списка котов. Значение sort проверяется сервисом, в запрос подставляется только колонка из списка
+var catSortColumns = map[string]string{
+	entities.CatSortName:      "c.name",
+	entities.CatSortAge:       "c.age",
+	entities.CatSortCreatedAt: "c.created_at",
+}
+
+// Страница котов по фильтру. Возвращает до limit + 1 котов: лишний показывает, что есть следующая страница
+
+func (r *catRepositoryImpl) GetAllCats(ctx context.Context, filter *entities.CatListFilter) ([]*entities.CatWithPrimePhoto, error) {
+	conditions, args := catListConditions(filter)
+
+	sortColumn := catSortColumns[filter.Sort]
+	direction, comparison := "DESC", "<"
+	if filter.Order == entities.SortOrderAsc {
+		direction, comparison = "ASC", ">"
+	}
+
+	// Продолжаем после последнего кота предыдущей страницы
+	if filter.Cursor != "" {
+		args = append(args, filter.CursorValue, filter.CursorID)
+		conditions = append(conditions, fmt.Sprintf("(%s, c.id) %s ($%d, $%d)", sortColumn, comparison, len(args)-1, len(args)))
+	}
+
+	query := catWithPrimePhotoQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s %s, c.id %s LIMIT $%d", sortColumn, direction, direction, len(args))
 
 	// Выполняем запрос
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanCatsWithPrimePhoto(rows)
+}
+
+func (r *catRepositoryImpl) CountCats(ctx context.Context, filter *entities.CatListFilter) (int, error) {
+	conditions, args := catListConditions(filter)
+
+	query := `SELECT COUNT(*) FROM cats c`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Считаем котов по фильтру (без учета курсора)
+	var total int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Условия списка котов по заданным фильтрам
+
+func catListConditions(filter *entities.CatListFilter) ([]string, []any) {
+	conditions := []string{}
+	args := []any{}
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.AgeMin != nil {
+		addCondition("c.age >= $%d", *filter.AgeMin)
+	}
+	if filter.AgeMax != nil {
+		addCondition("c.age <= $%d", *filter.AgeMax)
+	}
+	if filter.OwnerID != 0 {
+		addCondition("c.created_by = $%d", filter.OwnerID)
+	}
+	if !filter.CreatedFromTime.IsZero() {
+		addCondition("c.created_at >= $%d", filter.CreatedFromTime)
+	}
+	if !filter.CreatedToTime.IsZero() {
+		addCondition("c.created_at < $%d", filter.CreatedToTime)
+	}
+	if filter.HasPhoto != nil {
+		condition := "EXISTS (SELECT 1 FROM cat_photos WHERE cat_id = c.id)"
+		if !*filter.HasPhoto {
+			condition = "NOT " + condition
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, args
+}
+
+func (r *catRepositoryImpl) GetCatsByOwner(ctx context.Context, userID int) ([]*entities.CatWithPrimePhoto, error) {
+	// Запрос на получение котов пользователя с главным фото и количеством фото
+	query := catWithPrimePhotoQuery + ` WHERE c.created_by = $1 ORDER BY c.id;`
+
+	// Выполняем запрос
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCatsWithPrimePhoto(rows)
+}
+
+// Мэппинг строк catWithPrimePhotoQuery в котов с главным фото
+
+func scanCatsWithPrimePhoto(rows *sql.Rows) ([]*entities.CatWithPrimePhoto, error) {
 	var (
-		cats    []*entities.CatWithPrimePhoto
+		cats    = []*entities.CatWithPrimePhoto{}
 		photoID sql.NullInt64
 		url     sql.NullString
 	)
@@ -83,7 +196,7 @@ func (r *catRepositoryImpl) GetAllCats(ctx context.Context) ([]*entities.CatWith
 	for rows.Next() {
 		cat := &entities.CatWithPrimePhoto{}
 
-		err = rows.Scan(&cat.ID, &cat.Name, &cat.Age, &photoID, &url)
+		err := rows.Scan(&cat.ID, &cat.Name, &cat.Age, &cat.CreatedBy, &cat.CreatedAt, &photoID, &url, &cat.PhotoCount)
 		if err != nil {
 			return nil, err
 		}
@@ -102,7 +215,35 @@ func (r *catRepositoryImpl) GetAllCats(ctx context.Context) ([]*entities.CatWith
 		cats = append(cats, cat)
 	}
 
-	return cats, nil
+	return cats, rows.Err()
+}
+
+func (r *catRepositoryImpl) GetAllUserCats(ctx context.Context, userID int) ([]*entities.Cat, error) {
+	// Запрос на получение всех котов, созданных пользователем
+	query := `SELECT id, name, age, description, created_at FROM cats WHERE created_by = $1 ORDER BY id;`
+
+	// Выполняем запрос
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cats := []*entities.Cat{}
+
+	// Мэппинг ответа в структуру
+	for rows.Next() {
+		cat := &entities.Cat{CreatedBy: userID}
+
+		err = rows.Scan(&cat.ID, &cat.Name, &cat.Age, &cat.Description, &cat.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		cats = append(cats, cat)
+	}
+
+	return cats, rows.Err()
 }
 
 func (r *catRepositoryImpl) UpdateCatName(ctx context.Context, catID int, newName string) error {
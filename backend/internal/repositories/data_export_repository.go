@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type DataExportRepository interface {
+	SaveDataExport(ctx context.Context, dataExport *entities.DataExport, expiresIn time.Duration) error
+	GetDataExport(ctx context.Context, userID int) (*entities.DataExport, error)
+	DeleteDataExport(ctx context.Context, userID int) error
+	ClaimDataExportBuild(ctx context.Context, userID int, dataExportID string, expiresIn time.Duration) (bool, error)
+	ReleaseDataExportBuild(ctx context.Context, userID int, dataExportID string) error
+	UploadArchive(ctx context.Context, objectName string, archive io.Reader, size int64) error
+	GetArchiveUrl(ctx context.Context, objectName string, expiresIn time.Duration) (string, error)
+	DeleteArchive(ctx context.Context, objectName string) error
+}
+
+// Снятие блокировки сборки, только если ее держит указанная выгрузка
+var releaseDataExportBuildScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0
+`)
+
+type dataExportRepositoryImpl struct {
+	redis         *redis.Client
+	minioClient   *minio.Client
+	presignClient *minio.Client
+	bucketName    string
+}
+
+// presignClient - клиент с публичным адресом minio: адрес входит в подпись ссылки,
+// поэтому ссылку на скачивание нужно подписывать тем адресом, по которому к minio обращается пользователь
+
+func NewDataExportRepository(redis *redis.Client, minioClient, presignClient *minio.Client, bucketName string) DataExportRepository {
+	return &dataExportRepositoryImpl{
+		redis:         redis,
+		minioClient:   minioClient,
+		presignClient: presignClient,
+		bucketName:    bucketName,
+	}
+}
+
+func (r *dataExportRepositoryImpl) SaveDataExport(ctx context.Context, dataExport *entities.DataExport, expiresIn time.Duration) error {
+
+	// Сериализуем выгрузку
+	data, err := json.Marshal(dataExport)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data export: %s", err.Error())
+	}
+
+	// Сохраняем выгрузку, новая выгрузка заменяет предыдущую
+	err = r.redis.Set(ctx, utils.GetDataExportKey(dataExport.UserID), data, expiresIn).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save data export: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositoryImpl) GetDataExport(ctx context.Context, userID int) (*entities.DataExport, error) {
+
+	// Получаем последнюю выгрузку пользователя
+	data, err := r.redis.Get(ctx, utils.GetDataExportKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, entities.ErrDataExportNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %s", err.Error())
+	}
+
+	// Десериализуем выгрузку
+	dataExport := &entities.DataExport{}
+	if err = json.Unmarshal(data, dataExport); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data export: %s", err.Error())
+	}
+
+	return dataExport, nil
+}
+
+func (r *dataExportRepositoryImpl) DeleteDataExport(ctx context.Context, userID int) error {
+
+	// Удаляем выгрузку пользователя
+	err := r.redis.Del(ctx, utils.GetDataExportKey(userID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete data export: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositoryImpl) ClaimDataExportBuild(ctx context.Context, userID int, dataExportID string, expiresIn time.Duration) (bool, error) {
+
+	// Занимаем сборку для пользователя, если ее не держит другая выгрузка
+	claimed, err := r.redis.SetNX(ctx, utils.GetDataExportBuildKey(userID), dataExportID, expiresIn).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim data export build: %s", err.Error())
+	}
+
+	return claimed, nil
+}
+
+func (r *dataExportRepositoryImpl) ReleaseDataExportBuild(ctx context.Context, userID int, dataExportID string) error {
+
+	// Снимаем блокировку сборки, если она еще принадлежит этой выгрузке
+	err := releaseDataExportBuildScript.Run(ctx, r.redis, []string{utils.GetDataExportBuildKey(userID)}, dataExportID).Err()
+	if err != nil {
+		return fmt.Errorf("failed to release data export build: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositoryImpl) UploadArchive(ctx context.Context, objectName string, archive io.Reader, size int64) error {
+	// Сохраняем архив в закрытый бакет
+	_, err := r.minioClient.PutObject(ctx, r.bucketName, objectName, archive, size, minio.PutObjectOptions{
+		ContentType: "application/zip",
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositoryImpl) GetArchiveUrl(ctx context.Context, objectName string, expiresIn time.Duration) (string, error) {
+	// Подписываем временную ссылку на скачивание архива
+	url, err := r.presignClient.PresignedGetObject(ctx, r.bucketName, objectName, expiresIn, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return url.String(), nil
+}
+
+func (r *dataExportRepositoryImpl) DeleteArchive(ctx context.Context, objectName string) error {
+	// Удаляем архив из minio
+	err := r.minioClient.RemoveObject(ctx, r.bucketName, objectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type InvitationRepository interface {
+	CreateInvitation(ctx context.Context, invitation *entities.Invitation, codeHash string) error
+	GetAllInvitations(ctx context.Context, userID int) ([]*entities.Invitation, error)
+	DeleteInvitation(ctx context.Context, userID, invitationID int) error
+}
+
+type invitationRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) InvitationRepository {
+	return &invitationRepositoryImpl{db: db}
+}
+
+func (r *invitationRepositoryImpl) CreateInvitation(ctx context.Context, invitation *entities.Invitation, codeHash string) error {
+	query := `INSERT INTO invitations(created_by, code_prefix, code_hash, role, max_uses, expires_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, invitation.CreatedBy, invitation.Prefix, codeHash, invitation.Role, invitation.MaxUses, invitation.ExpiresAt).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *invitationRepositoryImpl) GetAllInvitations(ctx context.Context, userID int) ([]*entities.Invitation, error) {
+	query := `SELECT id, code_prefix, COALESCE(role, ''), max_uses, uses, expires_at, created_at FROM invitations WHERE created_by = $1 ORDER BY id`
+
+	// Получаем все приглашения пользователя
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []*entities.Invitation{}
+
+	// Меппим каждое приглашение в структуру
+	for rows.Next() {
+		invitation := &entities.Invitation{CreatedBy: userID}
+		err = rows.Scan(&invitation.ID, &invitation.Prefix, &invitation.Role, &invitation.MaxUses, &invitation.Uses, &invitation.ExpiresAt, &invitation.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		// Добавляем в массив приглашений
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (r *invitationRepositoryImpl) DeleteInvitation(ctx context.Context, userID, invitationID int) error {
+	query := `DELETE FROM invitations WHERE id = $1 AND created_by = $2`
+
+	// Удаляем приглашение пользователя
+	result, err := r.db.ExecContext(ctx, query, invitationID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем, что приглашение было удалено
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type ProfileRepository interface {
+	GetUserProfile(ctx context.Context, userID int) (*entities.UserProfile, error)
+	UpdateUserProfile(ctx context.Context, userID int, profileUpdateRequest *entities.UserProfileUpdateRequest) error
+	UploadAvatar(ctx context.Context, userID int, req *entities.AvatarUploadRequest) (string, error)
+	DeleteAvatar(ctx context.Context, userID int) error
+	DeleteAllUserAvatars(ctx context.Context, userID int) error
+}
+
+type profileRepositoryImpl struct {
+	db          *sql.DB
+	minioClient *minio.Client
+	endpoint    string
+	bucketName  string
+}
+
+func NewProfileRepository(db *sql.DB, minioClient *minio.Client, endpoint, bucketName string) ProfileRepository {
+	return &profileRepositoryImpl{
+		db:          db,
+		minioClient: minioClient,
+		endpoint:    endpoint,
+		bucketName:  bucketName,
+	}
+}
+
+func (r *profileRepositoryImpl) GetUserProfile(ctx context.Context, userID int) (*entities.UserProfile, error) {
+	// Профили пользователей, помеченных на удаление, не показываем
+	query := `SELECT login, display_name, bio, location, avatar_url, created_at FROM users WHERE id = $1 AND deletion_requested_at IS NULL`
+
+	// Получаем профиль пользователя
+	userProfile := &entities.UserProfile{ID: userID}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&userProfile.Login, &userProfile.DisplayName, &userProfile.Bio, &userProfile.Location, &userProfile.AvatarUrl, &userProfile.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return userProfile, nil
+}
+
+func (r *profileRepositoryImpl) UpdateUserProfile(ctx context.Context, userID int, profileUpdateRequest *entities.UserProfileUpdateRequest) error {
+	// Поля без значения (NULL) оставляем без изменений
+	query := `UPDATE users SET display_name = COALESCE($2, display_name), bio = COALESCE($3, bio), location = COALESCE($4, location) WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, userID, profileUpdateRequest.DisplayName, profileUpdateRequest.Bio, profileUpdateRequest.Location)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Загрузка аватара. Новый файл сохраняется под уникальным именем, предыдущий удаляется из minio после замены в бд
+
+func (r *profileRepositoryImpl) UploadAvatar(ctx context.Context, userID int, req *entities.AvatarUploadRequest) (string, error) {
+	// Генерируем уникальное имя файла
+	filename := utils.GenerateFilename(req.FileName, userID, "avatar")
+
+	// Сохраняем файл в Minio
+	_, err := r.minioClient.PutObject(
+		ctx,
+		r.bucketName,
+		filename,
+		req.File,
+		req.FileSize,
+		minio.PutObjectOptions{
+			ContentType: req.MimeType,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	// Создаем публичный url, формат: http://localhost:9000/bucket-name/filename
+	url := fmt.Sprintf("http://%s/%s/%s", r.endpoint, r.bucketName, filename)
+
+	// Заменяем аватар в бд
+	previousFilename, err := r.replaceAvatar(ctx, userID, &url, &filename)
+	if err != nil {
+		_ = r.minioClient.RemoveObject(ctx, r.bucketName, filename, minio.RemoveObjectOptions{})
+		return "", err
+	}
+
+	// Удаляем предыдущий аватар
+	if previousFilename != "" {
+		_ = r.minioClient.RemoveObject(ctx, r.bucketName, previousFilename, minio.RemoveObjectOptions{})
+	}
+
+	return url, nil
+}
+
+func (r *profileRepositoryImpl) DeleteAvatar(ctx context.Context, userID int) error {
+
+	// Убираем аватар из бд
+	previousFilename, err := r.replaceAvatar(ctx, userID, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	// Удаляем файл из minio
+	if previousFilename != "" {
+		err = r.minioClient.RemoveObject(ctx, r.bucketName, previousFilename, minio.RemoveObjectOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *profileRepositoryImpl) DeleteAllUserAvatars(ctx context.Context, userID int) error {
+	prefix := fmt.Sprintf("avatar/%d/", userID)
+
+	// Контекст отменяется при выходе, чтобы горутина и удаление в minio не остались висеть после ошибки
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Создаем канал для записи объектов
+	objectCh := make(chan minio.ObjectInfo)
+
+	// Создаем горутину, ошибка получения списка объектов возвращается после удаления уже найденных
+	var listErr error
+	go func() {
+		defer close(objectCh)
+
+		// Записываем в канал все аватары пользователя
+		for object := range r.minioClient.ListObjects(ctx, r.bucketName, minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+		}) {
+			if object.Err != nil {
+				listErr = object.Err
+				return
+			}
+			select {
+			case objectCh <- object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Удаляем все аватары пользователя
+	errorCh := r.minioClient.RemoveObjects(ctx, r.bucketName, objectCh, minio.RemoveObjectsOptions{})
+	for removeErr := range errorCh {
+		return fmt.Errorf("failed to remove avatar %s: %w", removeErr.ObjectName, removeErr.Err)
+	}
+
+	// Без полного списка объектов нельзя считать, что удалено все
+	if listErr != nil {
+		return fmt.Errorf("failed to list avatars: %w", listErr)
+	}
+
+	return nil
+}
+
+// Замена аватара в бд (nil - аватар удаляется). Возвращает имя файла предыдущего аватара.
+// Строка пользователя блокируется, чтобы параллельная загрузка не потеряла файл предыдущего аватара
+
+func (r *profileRepositoryImpl) replaceAvatar(ctx context.Context, userID int, url, filename *string) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx error: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Получаем предыдущий аватар
+	var previousFilename sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT avatar_filename FROM users WHERE id = $1 FOR UPDATE;`, userID).Scan(&previousFilename)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("user not found")
+	} else if err != nil {
+		return "", err
+	}
+
+	// Сохраняем новый аватар
+	_, err = tx.ExecContext(ctx, `UPDATE users SET avatar_url = $2, avatar_filename = $3 WHERE id = $1;`, userID, url, filename)
+	if err != nil {
+		return "", err
+	}
+
+	// Коммитим транзакцию
+	err = tx.Commit()
+	if err != nil {
+		return "", fmt.Errorf("commit tx error: %w", err)
+	}
+
+	return previousFilename.String, nil
+}
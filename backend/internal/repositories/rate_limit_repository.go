@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type RateLimitRepository interface {
+	Hit(ctx context.Context, policy *entities.RateLimitPolicy, subject string) (*entities.RateLimitResult, error)
+}
+
+type rateLimitRepositoryImpl struct {
+	redis *redis.Client
+}
+
+func NewRateLimitRepository(redis *redis.Client) RateLimitRepository {
+	return &rateLimitRepositoryImpl{redis: redis}
+}
+
+// Скользящее окно: в отсортированном сете хранятся времена запросов за последнее окно.
+// Возвращает {разрешен ли запрос, число запросов в окне, мс до освобождения места в окне}
+var slidingWindowScript = redis.NewScript(`
+	local now = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local limit = tonumber(ARGV[3])
+
+	redis.call("ZREMRANGEBYSCORE", KEYS[1], 0, now - window)
+
+	local count = redis.call("ZCARD", KEYS[1])
+	local allowed = 0
+	if count < limit then
+		redis.call("ZADD", KEYS[1], now, ARGV[4])
+		count = count + 1
+		allowed = 1
+	end
+	redis.call("PEXPIRE", KEYS[1], window)
+
+	local reset = window
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	if oldest[2] then
+		reset = tonumber(oldest[2]) + window - now
+	end
+
+	return {allowed, count, reset}
+`)
+
+func (r *rateLimitRepositoryImpl) Hit(ctx context.Context, policy *entities.RateLimitPolicy, subject string) (*entities.RateLimitResult, error) {
+	key := utils.GetRateLimitKey(policy.Name, subject)
+	now := time.Now().UnixMilli()
+
+	// Учитываем запрос в окне
+	values, err := slidingWindowScript.Run(ctx, r.redis, []string{key}, now, policy.Window.Milliseconds(), policy.Limit, utils.GenerateTokenID()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %s", err.Error())
+	}
+
+	return &entities.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  max(policy.Limit-int(values[1]), 0),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type TwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userID int) (*entities.TwoFactor, error)
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID int) error
+	UpdateTOTPLastStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
+
+type twoFactorRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return &twoFactorRepositoryImpl{db: db}
+}
+
+func (r *twoFactorRepositoryImpl) GetTwoFactor(ctx context.Context, userID int) (*entities.TwoFactor, error) {
+	query := `SELECT COALESCE(totp_secret, ''), totp_enabled, COALESCE(totp_last_step, 0) FROM users WHERE id = $1`
+
+	// Меппинг запроса в структуру
+	twoFactor := &entities.TwoFactor{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastUsedStep)
+	if err != nil {
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+func (r *twoFactorRepositoryImpl) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `UPDATE users SET totp_secret = $1, totp_last_step = NULL WHERE id = $2 AND totp_enabled = false`
+
+	// Сохраняем секрет, пока 2FA не подтверждена - секрет можно перевыпустить
+	result, err := r.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем что 2FA еще не включена
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("two-factor authentication already enabled")
+	}
+
+	return nil
+}
+
+func (r *twoFactorRepositoryImpl) EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	// Создаем транзакцию
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx error: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Включаем 2FA
+	_, err = tx.ExecContext(ctx, `UPDATE users SET totp_enabled = true WHERE id = $1;`, userID)
+	if err != nil {
+		return fmt.Errorf("enable two-factor error: %w", err)
+	}
+
+	// Заменяем коды восстановления
+	_, err = tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1;`, userID)
+	if err != nil {
+		return fmt.Errorf("delete recovery codes error: %w", err)
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO user_recovery_codes(user_id, code_hash) VALUES ($1, $2);`, userID, codeHash)
+		if err != nil {
+			return fmt.Errorf("add recovery code error: %w", err)
+		}
+	}
+
+	// Коммитим транзакцию
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit tx error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *twoFactorRepositoryImpl) DisableTwoFactor(ctx context.Context, userID int) error {
+	// Создаем транзакцию
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx error: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Выключаем 2FA и удаляем секрет
+	_, err = tx.ExecContext(ctx, `UPDATE users SET totp_enabled = false, totp_secret = NULL, totp_last_step = NULL WHERE id = $1;`, userID)
+	if err != nil {
+		return fmt.Errorf("disable two-factor error: %w", err)
+	}
+
+	// Удаляем коды восстановления
+	_, err = tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1;`, userID)
+	if err != nil {
+		return fmt.Errorf("delete recovery codes error: %w", err)
+	}
+
+	// Коммитим транзакцию
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit tx error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *twoFactorRepositoryImpl) UpdateTOTPLastStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE users SET totp_last_step = $1 WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`
+
+	// Запоминаем период использованного кода, коды этого и предыдущих периодов больше не принимаются
+	result, err := r.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *twoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE user_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	// Отмечаем код использованным, повторно он не сработает
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type UserIdentityRepository interface {
+	CreateUserIdentity(ctx context.Context, identity *entities.UserIdentity) error
+	GetUserIdentity(ctx context.Context, provider, subject string) (*entities.UserIdentity, error)
+	GetAllUserIdentities(ctx context.Context, userID int) ([]*entities.UserIdentity, error)
+	DeleteUserIdentity(ctx context.Context, userID, identityID int) error
+}
+
+type userIdentityRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewUserIdentityRepository(db *sql.DB) UserIdentityRepository {
+	return &userIdentityRepositoryImpl{db: db}
+}
+
+func (r *userIdentityRepositoryImpl) CreateUserIdentity(ctx context.Context, identity *entities.UserIdentity) error {
+	query := `INSERT INTO user_identities(user_id, provider, subject, email) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *userIdentityRepositoryImpl) GetUserIdentity(ctx context.Context, provider, subject string) (*entities.UserIdentity, error) {
+	query := `SELECT id, user_id, COALESCE(email, ''), created_at FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	// Меппинг запроса в структуру
+	identity := &entities.UserIdentity{Provider: provider, Subject: subject}
+	err := r.db.QueryRowContext(ctx, query, provider, subject).Scan(&identity.ID, &identity.UserID, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (r *userIdentityRepositoryImpl) GetAllUserIdentities(ctx context.Context, userID int) ([]*entities.UserIdentity, error) {
+	query := `SELECT id, provider, subject, COALESCE(email, ''), created_at FROM user_identities WHERE user_id = $1 ORDER BY id`
+
+	// Получаем все внешние аккаунты пользователя
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*entities.UserIdentity{}
+
+	// Меппим каждый аккаунт в структуру
+	for rows.Next() {
+		identity := &entities.UserIdentity{UserID: userID}
+		err = rows.Scan(&identity.ID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		// Добавляем в массив аккаунтов
+		identities = append(identities, identity)
+	}
+
+	return identities, nil
+}
+
+func (r *userIdentityRepositoryImpl) DeleteUserIdentity(ctx context.Context, userID, identityID int) error {
+	query := `DELETE FROM user_identities WHERE id = $1 AND user_id = $2`
+
+	// Удаляем привязку внешнего аккаунта
+	result, err := r.db.ExecContext(ctx, query, identityID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем что привязка была удалена
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("identity not found")
+	}
+
+	return nil
+}
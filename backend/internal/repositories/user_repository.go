@@ -3,17 +3,35 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/pkg/utils"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *entities.User) error
+	CreateUserWithInvitation(ctx context.Context, user *entities.User, invitationCodeHash string) error
 	GetUserByID(ctx context.Context, id int) (*entities.UserGet, error)
+	GetUserLogin(ctx context.Context, id int) (string, error)
 	GetUserByLogin(ctx context.Context, login string) (*entities.User, error)
-	GetAllUsers(ctx context.Context) ([]*entities.UserGet, error)
+	GetUserByEmail(ctx context.Context, email string) (*entities.User, error)
+	GetUserPasswordHash(ctx context.Context, id int) (string, error)
+	GetAllUsers(ctx context.Context, filter *entities.UserListFilter) ([]*entities.UserListItem, error)
+	CountUsers(ctx context.Context, filter *entities.UserListFilter) (int, error)
 	UpdateUserPassword(ctx context.Context, id int, passwordHash string) error
+	UpdateUserRole(ctx context.Context, id int, role string) error
+	GetUserEmail(ctx context.Context, id int) (*entities.UserEmail, error)
+	UpdateUserEmail(ctx context.Context, id int, email string) error
+	SetUserEmailVerified(ctx context.Context, id int, email string) error
+	ScheduleUserDeletion(ctx context.Context, id, requestedBy int, gracePeriod time.Duration) (*entities.UserDeletion, error)
+	GetUserDeletion(ctx context.Context, id int) (*entities.UserDeletion, error)
+	CancelUserDeletion(ctx context.Context, id int) (bool, error)
+	ClaimUsersForPurge(ctx context.Context, limit int, staleAfter time.Duration) ([]int, error)
 	DeleteUser(ctx context.Context, id int) error
 }
 
@@ -26,9 +44,9 @@ func NewUserRepository(db *sql.DB) UserRepository {
 }
 
 func (r *userRepositoryImpl) CreateUser(ctx context.Context, user *entities.User) error {
-	query := `INSERT INTO users(login, password_hash) VALUES ($1, $2) RETURNING id`
+	query := `INSERT INTO users(login, email, password_hash) VALUES ($1, NULLIF($2, ''), $3) RETURNING id`
 
-	err := r.db.QueryRowContext(ctx, query, user.Login, user.PasswordHash).Scan(&user.ID)
+	err := r.db.QueryRowContext(ctx, query, user.Login, user.Email, user.PasswordHash).Scan(&user.ID)
 	if err != nil {
 		return err
 	}
@@ -36,15 +54,50 @@ func (r *userRepositoryImpl) CreateUser(ctx context.Context, user *entities.User
 	return nil
 }
 
+// Создание пользователя по коду приглашения. Использование кода и создание пользователя выполняются в одной транзакции:
+// код не расходуется, если пользователя создать не удалось, и не может быть использован сверх лимита при параллельных регистрациях
+
+func (r *userRepositoryImpl) CreateUserWithInvitation(ctx context.Context, user *entities.User, invitationCodeHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx error: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Используем приглашение, если оно не истекло и не исчерпано, и получаем назначенную им роль
+	var role sql.NullString
+	err = tx.QueryRowContext(ctx, `UPDATE invitations SET uses = uses + 1 WHERE code_hash = $1 AND uses < max_uses AND (expires_at IS NULL OR expires_at > NOW()) RETURNING role;`, invitationCodeHash).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.ErrInvalidInvitation
+	} else if err != nil {
+		return fmt.Errorf("use invitation error: %w", err)
+	}
+
+	// Создаем пользователя с ролью из приглашения (если роль не назначена - обычный пользователь)
+	err = tx.QueryRowContext(ctx, `INSERT INTO users(login, email, password_hash, role) VALUES ($1, NULLIF($2, ''), $3, COALESCE($4, 'user')) RETURNING id;`, user.Login, user.Email, user.PasswordHash, role).Scan(&user.ID)
+	if err != nil {
+		return fmt.Errorf("create user error: %w", err)
+	}
+
+	// Коммитим транзакцию
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit tx error: %w", err)
+	}
+
+	return nil
+}
+
 func (r *userRepositoryImpl) GetUserByID(ctx context.Context, id int) (*entities.UserGet, error) {
-	query := `SELECT login, created_at FROM users WHERE id = $1`
+	// Пользователей, помеченных на удаление, не показываем, как в профиле и списке пользователей
+	query := `SELECT login, role, display_name, bio, location, avatar_url, created_at FROM users WHERE id = $1 AND deletion_requested_at IS NULL`
 
 	// Получаем пользователя по ID
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	// Меппинг запроса в структуру
 	user := &entities.UserGet{ID: id}
-	err := row.Scan(&user.Login, &user.CreatedAt)
+	err := row.Scan(&user.Login, &user.Role, &user.DisplayName, &user.Bio, &user.Location, &user.AvatarUrl, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -52,15 +105,29 @@ func (r *userRepositoryImpl) GetUserByID(ctx context.Context, id int) (*entities
 	return user, nil
 }
 
+// Логин пользователя, в том числе помеченного на удаление: вход и сброс пароля отменяют удаление, поэтому должны его находить
+
+func (r *userRepositoryImpl) GetUserLogin(ctx context.Context, id int) (string, error) {
+	query := `SELECT login FROM users WHERE id = $1`
+
+	var login string
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&login)
+	if err != nil {
+		return "", err
+	}
+
+	return login, nil
+}
+
 func (r *userRepositoryImpl) GetUserByLogin(ctx context.Context, login string) (*entities.User, error) {
-	query := `SELECT id, password_hash FROM users WHERE login = $1`
+	query := `SELECT id, COALESCE(email, ''), email_verified, password_hash FROM users WHERE login = $1`
 
 	// Получаем пользователя по login
 	row := r.db.QueryRowContext(ctx, query, login)
 
 	// Меппинг запроса в структуру
 	user := &entities.User{Login: login}
-	err := row.Scan(&user.ID, &user.PasswordHash)
+	err := row.Scan(&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash)
 	if err != nil {
 		return nil, err
 	}
@@ -68,22 +135,70 @@ func (r *userRepositoryImpl) GetUserByLogin(ctx context.Context, login string) (
 	return user, nil
 }
 
-func (r *userRepositoryImpl) GetAllUsers(ctx context.Context) ([]*entities.UserGet, error) {
-	query := `SELECT id, login, created_at FROM users`
+func (r *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	query := `SELECT id, login, email_verified FROM users WHERE lower(email) = lower($1) AND email_verified`
+
+	// Получаем пользователя по подтвержденному email, неподтвержденный адрес никому не принадлежит
+	row := r.db.QueryRowContext(ctx, query, email)
+
+	// Меппинг запроса в структуру
+	user := &entities.User{Email: email}
+	err := row.Scan(&user.ID, &user.Login, &user.EmailVerified)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepositoryImpl) GetUserPasswordHash(ctx context.Context, id int) (string, error) {
+	query := `SELECT password_hash FROM users WHERE id = $1`
+
+	// Получаем хеш пароля пользователя
+	var passwordHash string
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&passwordHash)
+	if err != nil {
+		return "", err
+	}
+
+	return passwordHash, nil
+}
+
+// Страница пользователей по фильтру. Возвращает до limit + 1 пользователей: лишний показывает, что есть следующая страница
 
-	// Получаем всех пользователей
-	rows, err := r.db.QueryContext(ctx, query)
+func (r *userRepositoryImpl) GetAllUsers(ctx context.Context, filter *entities.UserListFilter) ([]*entities.UserListItem, error) {
+	conditions, args := userListConditions(filter)
+
+	// Продолжаем после последнего пользователя предыдущей страницы
+	if filter.Cursor != "" {
+		comparison := "<"
+		if filter.Order == entities.SortOrderAsc {
+			comparison = ">"
+		}
+		args = append(args, filter.CursorCreatedAt, filter.CursorID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+
+	direction := "DESC"
+	if filter.Order == entities.SortOrderAsc {
+		direction = "ASC"
+	}
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`SELECT id, login, role, display_name, bio, location, avatar_url, created_at FROM users WHERE %s ORDER BY created_at %s, id %s LIMIT $%d`, strings.Join(conditions, " AND "), direction, direction, len(args))
+
+	// Получаем пользователей
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []*entities.UserGet
+	users := []*entities.UserListItem{}
 
 	// Меппим каждого пользователя в структуру
 	for rows.Next() {
-		user := &entities.UserGet{}
-		err = rows.Scan(&user.ID, &user.Login, &user.CreatedAt)
+		user := &entities.UserListItem{UserGet: &entities.UserGet{}}
+		err = rows.Scan(&user.ID, &user.Login, &user.Role, &user.DisplayName, &user.Bio, &user.Location, &user.AvatarUrl, &user.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -92,7 +207,39 @@ func (r *userRepositoryImpl) GetAllUsers(ctx context.Context) ([]*entities.UserG
 		users = append(users, user)
 	}
 
-	return users, nil
+	return users, rows.Err()
+}
+
+func (r *userRepositoryImpl) CountUsers(ctx context.Context, filter *entities.UserListFilter) (int, error) {
+	conditions, args := userListConditions(filter)
+	query := `SELECT COUNT(*) FROM users WHERE ` + strings.Join(conditions, " AND ")
+
+	// Считаем пользователей по фильтру (без учета курсора)
+	var total int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Условия списка пользователей: помеченные на удаление не показываются, логин ищется без учета регистра
+
+func userListConditions(filter *entities.UserListFilter) ([]string, []any) {
+	conditions := []string{"deletion_requested_at IS NULL"}
+	args := []any{}
+
+	if filter.Search != "" {
+		pattern := utils.EscapeLikePattern(filter.Search) + "%"
+		if filter.SearchMode == entities.UserSearchContains {
+			pattern = "%" + pattern
+		}
+		args = append(args, pattern)
+		conditions = append(conditions, fmt.Sprintf(`login ILIKE $%d ESCAPE '\'`, len(args)))
+	}
+
+	return conditions, args
 }
 
 func (r *userRepositoryImpl) UpdateUserPassword(ctx context.Context, id int, passwordHash string) error {
@@ -107,6 +254,178 @@ func (r *userRepositoryImpl) UpdateUserPassword(ctx context.Context, id int, pas
 	return nil
 }
 
+func (r *userRepositoryImpl) UpdateUserRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role = $1 WHERE id = $2`
+
+	// Обновляем роль пользователя
+	result, err := r.db.ExecContext(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем что пользователь был найден
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *userRepositoryImpl) GetUserEmail(ctx context.Context, id int) (*entities.UserEmail, error) {
+	query := `SELECT COALESCE(email, ''), email_verified FROM users WHERE id = $1`
+
+	// Получаем email пользователя
+	row := r.db.QueryRowContext(ctx, query, id)
+
+	// Меппинг запроса в структуру
+	userEmail := &entities.UserEmail{}
+	err := row.Scan(&userEmail.Email, &userEmail.Verified)
+	if err != nil {
+		return nil, err
+	}
+
+	return userEmail, nil
+}
+
+func (r *userRepositoryImpl) UpdateUserEmail(ctx context.Context, id int, email string) error {
+	query := `UPDATE users SET email = NULLIF($1, ''), email_verified = false WHERE id = $2`
+
+	// Обновляем email пользователя, новый email требует подтверждения
+	_, err := r.db.ExecContext(ctx, query, email, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *userRepositoryImpl) SetUserEmailVerified(ctx context.Context, id int, email string) error {
+	query := `UPDATE users SET email_verified = true WHERE id = $1 AND email = $2`
+
+	// Отмечаем email пользователя подтвержденным, если он не изменился и не подтвержден другим пользователем
+	result, err := r.db.ExecContext(ctx, query, id, email)
+	if isEmailTakenError(err) {
+		return entities.ErrEmailTaken
+	} else if err != nil {
+		return err
+	}
+
+	// Проверяем что пользователь был найден
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("user or email not found")
+	}
+
+	return nil
+}
+
+// Пометка пользователя на удаление. Повторный запрос обновляет срок и автора запроса, пока очистка не началась
+
+func (r *userRepositoryImpl) ScheduleUserDeletion(ctx context.Context, id, requestedBy int, gracePeriod time.Duration) (*entities.UserDeletion, error) {
+	query := `UPDATE users SET deletion_requested_at = NOW(), deletion_requested_by = $2, purge_after = NOW() + $3 * INTERVAL '1 second' WHERE id = $1 AND purge_started_at IS NULL RETURNING deletion_requested_at, purge_after`
+
+	// Помечаем пользователя на удаление
+	userDeletion := &entities.UserDeletion{RequestedBy: requestedBy}
+	err := r.db.QueryRowContext(ctx, query, id, requestedBy, gracePeriod.Seconds()).Scan(&userDeletion.RequestedAt, &userDeletion.PurgeAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return userDeletion, nil
+}
+
+// Запрос на удаление пользователя, nil - пользователь не помечен на удаление
+
+func (r *userRepositoryImpl) GetUserDeletion(ctx context.Context, id int) (*entities.UserDeletion, error) {
+	query := `SELECT deletion_requested_at, deletion_requested_by, purge_after, purge_started_at FROM users WHERE id = $1`
+
+	var (
+		requestedAt    sql.NullTime
+		requestedBy    sql.NullInt64
+		purgeAfter     sql.NullTime
+		purgeStartedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&requestedAt, &requestedBy, &purgeAfter, &purgeStartedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !requestedAt.Valid {
+		return nil, nil
+	}
+
+	// Меппинг запроса в структуру
+	userDeletion := &entities.UserDeletion{
+		RequestedAt: requestedAt.Time,
+		RequestedBy: int(requestedBy.Int64),
+		PurgeAfter:  purgeAfter.Time,
+	}
+	if purgeStartedAt.Valid {
+		userDeletion.PurgeStartedAt = &purgeStartedAt.Time
+	}
+
+	return userDeletion, nil
+}
+
+// Отмена удаления. Возвращает false, если отменять нечего или очистка уже началась
+
+func (r *userRepositoryImpl) CancelUserDeletion(ctx context.Context, id int) (bool, error) {
+	query := `UPDATE users SET deletion_requested_at = NULL, deletion_requested_by = NULL, purge_after = NULL WHERE id = $1 AND deletion_requested_at IS NOT NULL AND purge_started_at IS NULL`
+
+	// Снимаем пометку на удаление
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// Выбор пользователей для очистки: срок удаления истек, очистка не началась или прервалась (началась раньше staleAfter).
+// Отметка о начале очистки запрещает отмену удаления, SKIP LOCKED не дает двум экземплярам приложения взять одного пользователя
+
+func (r *userRepositoryImpl) ClaimUsersForPurge(ctx context.Context, limit int, staleAfter time.Duration) ([]int, error) {
+	query := `
+		UPDATE users SET purge_started_at = NOW()
+		WHERE id IN (
+			SELECT id FROM users
+			WHERE purge_after <= NOW() AND (purge_started_at IS NULL OR purge_started_at < NOW() - $2 * INTERVAL '1 second')
+			ORDER BY purge_after
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`
+
+	// Отмечаем начало очистки
+	rows, err := r.db.QueryContext(ctx, query, limit, staleAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		if err = rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
 func (r *userRepositoryImpl) DeleteUser(ctx context.Context, id int) error {
 	query := `DELETE FROM users WHERE id = $1`
 
@@ -126,3 +445,10 @@ func (r *userRepositoryImpl) DeleteUser(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// Нарушение уникальности email (индекс по подтвержденному email без учета регистра)
+
+func isEmailTakenError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_email_lower_key"
+}
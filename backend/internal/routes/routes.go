@@ -1,9 +1,26 @@
 package routes
 
 import (
+	"time"
+
 	swagger "github.com/arsmn/fiber-swagger/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/unwelcome/iqjtest/internal/dependency_injection"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// Политики ограничения частоты запросов.
+// Анонимные запросы ограничиваются по IP, авторизованные - по userID
+
+var (
+	registerRateLimit    = &entities.RateLimitPolicy{Name: "register", Limit: 10, Window: time.Hour}
+	loginRateLimit       = &entities.RateLimitPolicy{Name: "login", Limit: 30, Window: time.Minute}
+	refreshRateLimit     = &entities.RateLimitPolicy{Name: "refresh", Limit: 30, Window: time.Minute}
+	passwordRateLimit    = &entities.RateLimitPolicy{Name: "password", Limit: 10, Window: time.Hour}
+	emailRateLimit       = &entities.RateLimitPolicy{Name: "email", Limit: 10, Window: time.Hour}
+	authRateLimit        = &entities.RateLimitPolicy{Name: "auth", Limit: 300, Window: time.Minute}
+	photoUploadRateLimit = &entities.RateLimitPolicy{Name: "photo_upload", Limit: 60, Window: time.Hour}
+	dataExportRateLimit  = &entities.RateLimitPolicy{Name: "data_export", Limit: 5, Window: 24 * time.Hour}
 )
 
 func SetupRoutes(app *fiber.App, container *dependency_injection.Container) {
@@ -13,9 +30,21 @@ func SetupRoutes(app *fiber.App, container *dependency_injection.Container) {
 	// Группировка всех api роутов
 	api := app.Group("/api")
 
+	// Проверка CSRF токена для запросов с токенами в cookie
+	api.Use("/auth", container.CSRFMiddleware)
+	api.Use("/refresh", container.CSRFMiddleware)
+
 	// Проверка авторизации
 	api.Use("/auth", container.AuthMiddleware)
 
+	// Ограничение частоты запросов
+	api.Use("/register", container.RateLimitMiddleware(registerRateLimit))
+	api.Use("/login", container.RateLimitMiddleware(loginRateLimit))
+	api.Use("/refresh", container.RateLimitMiddleware(refreshRateLimit))
+	api.Use("/password", container.RateLimitMiddleware(passwordRateLimit))
+	api.Use("/email", container.RateLimitMiddleware(emailRateLimit))
+	api.Use("/auth", container.RateLimitMiddleware(authRateLimit))
+
 	// Инициализация swagger
 	// swag init -o ./api/docs --dir ./cmd/api,./internal/entities,./internal/handlers
 	api.Get("/swagger/*", swagger.HandlerDefault)
@@ -24,33 +53,99 @@ func SetupRoutes(app *fiber.App, container *dependency_injection.Container) {
 	api.Get("/health", container.HealthHandler.Health)
 
 	// Auth запросы
+	api.Get("/.well-known/jwks.json", container.AuthHandler.JWKS)
 	api.Post("/register", container.AuthHandler.Register)
 	api.Post("/login", container.AuthHandler.Login)
+	api.Post("/login/2fa", container.AuthHandler.LoginTwoFactor)
 	api.Post("/refresh", container.AuthHandler.Refresh)
-	api.Delete("/auth/logout", container.AuthHandler.Logout)
-	api.Delete("/auth/user/delete", container.AuthHandler.DeleteUser)
+	api.Post("/password/reset", container.AuthHandler.RequestPasswordReset)
+	api.Post("/password/reset/confirm", container.AuthHandler.ConfirmPasswordReset)
+	api.Post("/email/verify/confirm", container.EmailHandler.ConfirmEmailVerification)
 
-	// User запросы
-	api.Get("/auth/user/all", container.UserHandler.GetAllUsers)
-	api.Get("/auth/user/:id", container.UserHandler.GetUserByID)
-	api.Patch("/auth/user/password", container.UserHandler.UpdateUserPassword)
+	// OIDC запросы
+	api.Get("/oidc/login", container.RateLimitMiddleware(loginRateLimit), container.OIDCHandler.Login)
+	api.Get("/oidc/callback", container.RateLimitMiddleware(loginRateLimit), container.OIDCHandler.Callback)
 
-	// Cat запросы
-	api.Get("/auth/cat/all", container.CatHandler.GetAllCats)
-	api.Get("/auth/cat/id/:id", container.CatHandler.GetCatByID)
-	api.Post("/auth/cat/create", container.CatHandler.CreateCat)
+	// Cat запросы (доступны и по API ключу с нужным scope)
+	api.Get("/auth/cat/all", container.RequireScopeMiddleware(entities.ScopeCatsRead), container.CatHandler.GetAllCats)
+	api.Get("/auth/cat/id/:id", container.RequireScopeMiddleware(entities.ScopeCatsRead), container.CatHandler.GetCatByID)
+	api.Get("/auth/user/me/cats", container.RequireScopeMiddleware(entities.ScopeCatsRead), container.CatHandler.GetMyCats)
+	api.Get("/auth/user/:id/cats", container.RequireScopeMiddleware(entities.ScopeCatsRead), container.CatHandler.GetUserCats)
+	api.Post("/auth/cat/create", container.RequireScopeMiddleware(entities.ScopeCatsWrite), container.RateLimitMiddleware(photoUploadRateLimit), container.EmailVerifiedMiddleware, container.CatHandler.CreateCat)
 
 	// Middleware проверки прав собственности пользователя на кота
 	api.Use("/auth/cat/mw/:id", container.CatOwnershipMiddleware)
-	api.Put("/auth/cat/mw/:id", container.CatHandler.UpdateCat)
-	api.Patch("/auth/cat/mw/:id/name", container.CatHandler.UpdateCatName)
-	api.Patch("/auth/cat/mw/:id/age", container.CatHandler.UpdateCatAge)
-	api.Patch("/auth/cat/mw/:id/description", container.CatHandler.UpdateCatDescription)
-	api.Delete("/auth/cat/mw/:id", container.CatHandler.DeleteCat)
-
-	// Cat photo запросы
-	api.Get("/auth/cat/photo/:photoID", container.CatPhotoHandler.GetCatPhotoByID)
-	api.Post("/auth/cat/mw/:id/photo/add", container.CatPhotoHandler.AddCatPhotos)
-	api.Patch("/auth/cat/mw/:id/photo/:photoID/primary", container.CatPhotoHandler.SetCatPhotoPrimary)
-	api.Delete("/auth/cat/mw/:id/photo/:photoID", container.CatPhotoHandler.DeleteCatPhoto)
+	api.Put("/auth/cat/mw/:id", container.RequireScopeMiddleware(entities.ScopeCatsWrite), container.CatHandler.UpdateCat)
+	api.Patch("/auth/cat/mw/:id/name", container.RequireScopeMiddleware(entities.ScopeCatsWrite), container.CatHandler.UpdateCatName)
+	api.Patch("/auth/cat/mw/:id/age", container.RequireScopeMiddleware(entities.ScopeCatsWrite), container.CatHandler.UpdateCatAge)
+	api.Patch("/auth/cat/mw/:id/description", container.RequireScopeMiddleware(entities.ScopeCatsWrite), container.CatHandler.UpdateCatDescription)
+	api.Delete("/auth/cat/mw/:id", container.RequireScopeMiddleware(entities.ScopeCatsWrite), container.CatHandler.DeleteCat)
+
+	// Cat photo запросы (доступны и по API ключу с нужным scope)
+	api.Get("/auth/cat/photo/:photoID", container.RequireScopeMiddleware(entities.ScopeCatsRead), container.CatPhotoHandler.GetCatPhotoByID)
+	api.Post("/auth/cat/mw/:id/photo/add", container.RequireScopeMiddleware(entities.ScopePhotosWrite), container.RateLimitMiddleware(photoUploadRateLimit), container.CatPhotoHandler.AddCatPhotos)
+	api.Patch("/auth/cat/mw/:id/photo/:photoID/primary", container.RequireScopeMiddleware(entities.ScopePhotosWrite), container.CatPhotoHandler.SetCatPhotoPrimary)
+	api.Delete("/auth/cat/mw/:id/photo/:photoID", container.RequireScopeMiddleware(entities.ScopePhotosWrite), container.CatPhotoHandler.DeleteCatPhoto)
+
+	// Остальные запросы доступны только по access токену.
+	// Маршруты выше этой строки обрабатываются раньше, поэтому запросы по API ключу к ним проходят
+	api.Use("/auth", container.RequireSessionMiddleware)
+
+	// Session запросы
+	api.Delete("/auth/logout", container.AuthHandler.Logout)
+	api.Get("/auth/session/all", container.AuthHandler.GetAllSessions)
+	api.Delete("/auth/session/others", container.AuthHandler.DeleteOtherSessions)
+	api.Delete("/auth/session/:id", container.AuthHandler.DeleteSession)
+	// Смена пароля, удаление аккаунта и изменение способов входа недоступны по токену имперсонации
+	api.Patch("/auth/user/password", container.ForbidImpersonationMiddleware, container.RateLimitMiddleware(passwordRateLimit), container.AuthHandler.UpdateUserPassword)
+	api.Delete("/auth/user/delete", container.ForbidImpersonationMiddleware, container.AuthHandler.DeleteUser)
+
+	// API key запросы
+	api.Get("/auth/apikey/all", container.APIKeyHandler.GetAllAPIKeys)
+	api.Post("/auth/apikey/create", container.ForbidImpersonationMiddleware, container.APIKeyHandler.CreateAPIKey)
+	api.Delete("/auth/apikey/:id", container.APIKeyHandler.DeleteAPIKey)
+
+	// Коды приглашений
+	api.Get("/auth/invitation/all", container.InvitationHandler.GetAllInvitations)
+	api.Post("/auth/invitation/create", container.ForbidImpersonationMiddleware, container.InvitationHandler.CreateInvitation)
+	api.Delete("/auth/invitation/:id", container.InvitationHandler.DeleteInvitation)
+
+	// Привязка аккаунтов OIDC провайдера
+	api.Post("/auth/oidc/link", container.ForbidImpersonationMiddleware, container.OIDCHandler.LinkIdentity)
+	api.Get("/auth/oidc/identities", container.OIDCHandler.GetAllIdentities)
+	api.Delete("/auth/oidc/identities/:id", container.ForbidImpersonationMiddleware, container.OIDCHandler.DeleteIdentity)
+
+	// 2FA запросы
+	api.Use("/auth/2fa", container.ForbidImpersonationMiddleware)
+	api.Post("/auth/2fa/enroll", container.TwoFactorHandler.EnrollTwoFactor)
+	api.Post("/auth/2fa/confirm", container.TwoFactorHandler.ConfirmTwoFactor)
+	api.Delete("/auth/2fa", container.TwoFactorHandler.DisableTwoFactor)
+
+	// Email запросы
+	api.Get("/auth/user/email", container.EmailHandler.GetUserEmail)
+	api.Patch("/auth/user/email", container.ForbidImpersonationMiddleware, container.RateLimitMiddleware(emailRateLimit), container.EmailHandler.UpdateUserEmail)
+	api.Post("/auth/user/email/verify", container.RateLimitMiddleware(emailRateLimit), container.EmailHandler.SendVerificationEmail)
+
+	// Профиль пользователя
+	api.Get("/auth/user/profile", container.ProfileHandler.GetMyProfile)
+	api.Patch("/auth/user/profile", container.ProfileHandler.UpdateProfile)
+	api.Put("/auth/user/avatar", container.RateLimitMiddleware(photoUploadRateLimit), container.ProfileHandler.UploadAvatar)
+	api.Delete("/auth/user/avatar", container.ProfileHandler.DeleteAvatar)
+
+	// Выгрузка данных пользователя (по токену имперсонации запустить и скачать выгрузку нельзя)
+	api.Post("/auth/user/export", container.ForbidImpersonationMiddleware, container.RateLimitMiddleware(dataExportRateLimit), container.DataExportHandler.StartDataExport)
+	api.Get("/auth/user/export", container.DataExportHandler.GetDataExport)
+	api.Get("/auth/user/export/download", container.ForbidImpersonationMiddleware, container.DataExportHandler.GetDataExportDownload)
+
+	// Admin запросы
+	api.Use("/auth/admin", container.RequireRoleMiddleware(entities.RoleAdmin))
+	api.Patch("/auth/admin/user/:id/role", container.AuthHandler.AdminUpdateUserRole)
+	api.Delete("/auth/admin/user/:id", container.AuthHandler.AdminDeleteUser)
+	api.Post("/auth/admin/user/:id/impersonate", container.AuthHandler.AdminImpersonateUser)
+	api.Get("/auth/admin/audit", container.AuditHandler.GetAuditEvents)
+
+	// User запросы
+	api.Get("/auth/user/all", container.RequireRoleMiddleware(entities.RoleAdmin), container.UserHandler.GetAllUsers)
+	api.Get("/auth/user/:id", container.UserHandler.GetUserByID)
+	api.Get("/auth/user/:id/profile", container.ProfileHandler.GetUserProfile)
 }
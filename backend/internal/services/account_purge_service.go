@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+)
+
+type AccountPurgeService interface {
+	Run(ctx context.Context)
+	PurgeUsers(ctx context.Context) (int, error)
+}
+
+type accountPurgeServiceImpl struct {
+	userRepository       repositories.UserRepository
+	catRepository        repositories.CatRepository
+	catPhotoRepository   repositories.CatPhotoRepository
+	profileRepository    repositories.ProfileRepository
+	dataExportRepository repositories.DataExportRepository
+	auditLogger          AuditLogger
+	logger               zerolog.Logger
+
+	interval     time.Duration
+	batchSize    int
+	purgeTimeout time.Duration
+}
+
+func NewAccountPurgeService(
+	userRepository repositories.UserRepository,
+	catRepository repositories.CatRepository,
+	catPhotoRepository repositories.CatPhotoRepository,
+	profileRepository repositories.ProfileRepository,
+	dataExportRepository repositories.DataExportRepository,
+	auditLogger AuditLogger,
+	logger zerolog.Logger,
+	interval time.Duration,
+	batchSize int,
+	purgeTimeout time.Duration,
+) AccountPurgeService {
+	return &accountPurgeServiceImpl{
+		userRepository:       userRepository,
+		catRepository:        catRepository,
+		catPhotoRepository:   catPhotoRepository,
+		profileRepository:    profileRepository,
+		dataExportRepository: dataExportRepository,
+		auditLogger:          auditLogger,
+		logger:               logger,
+		interval:             interval,
+		batchSize:            batchSize,
+		purgeTimeout:         purgeTimeout,
+	}
+}
+
+// Фоновое удаление пользователей, у которых истек срок на отмену удаления.
+// Работает до отмены контекста
+
+func (s *accountPurgeServiceImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// Удаляем пользователей
+		purged, err := s.PurgeUsers(ctx)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to purge users")
+		} else if purged > 0 {
+			s.logger.Info().Int("purged", purged).Msg("users purged")
+		}
+
+		// Ждем следующего запуска
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *accountPurgeServiceImpl) PurgeUsers(ctx context.Context) (int, error) {
+	purged := 0
+
+	for {
+		// Забираем пачку пользователей на удаление. Пользователи, удаление которых не завершилось за purgeTimeout, забираются повторно
+		userIDs, err := s.userRepository.ClaimUsersForPurge(ctx, s.batchSize, s.purgeTimeout)
+		if err != nil {
+			return purged, fmt.Errorf("purge users error: %w", err)
+		}
+
+		// Удаляем пользователей по одному, ошибка одного не останавливает остальных
+		for _, userID := range userIDs {
+			if s.purgeUser(ctx, userID) == nil {
+				purged++
+			}
+		}
+
+		// Неполная пачка - пользователей на удаление больше нет
+		if len(userIDs) < s.batchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (s *accountPurgeServiceImpl) purgeUser(ctx context.Context, userID int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.purgeTimeout)
+	defer cancel()
+
+	// Удаляем пользователя и записываем результат в журнал аудита
+	catsCount, err := s.deleteUserData(ctx, userID)
+	event := newAuditEvent(0, &entities.ClientInfo{}, entities.AuditActionPurgeUser, entities.AuditTargetUser, strconv.Itoa(userID), err)
+	if err == nil {
+		event.Details = fmt.Sprintf("cats: %d", catsCount)
+	}
+	s.auditLogger.Log(ctx, event)
+
+	if err != nil {
+		s.logger.Error().Err(err).Int("userID", userID).Msg("failed to purge user")
+	}
+
+	return err
+}
+
+func (s *accountPurgeServiceImpl) deleteUserData(ctx context.Context, userID int) (int, error) {
+
+	// Получаем котиков пользователя
+	cats, err := s.catRepository.GetAllUserCats(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("purge user error: %w", err)
+	}
+
+	// Удаляем фото котиков из minio, записи в postgres удалятся каскадно вместе с пользователем
+	for _, cat := range cats {
+		err = s.catPhotoRepository.DeleteAllCatPhotos(ctx, cat.ID)
+		if err != nil {
+			return 0, fmt.Errorf("purge user error: %w", err)
+		}
+	}
+
+	// Удаляем аватары пользователя
+	err = s.profileRepository.DeleteAllUserAvatars(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("purge user error: %w", err)
+	}
+
+	// Удаляем архив выгрузки данных
+	dataExport, err := s.dataExportRepository.GetDataExport(ctx, userID)
+	if err != nil && !errors.Is(err, entities.ErrDataExportNotFound) {
+		return 0, fmt.Errorf("purge user error: %w", err)
+	}
+	if dataExport != nil {
+		err = s.dataExportRepository.DeleteArchive(ctx, dataExportObjectName(dataExport))
+		if err != nil {
+			return 0, fmt.Errorf("purge user error: %w", err)
+		}
+		err = s.dataExportRepository.DeleteDataExport(ctx, userID)
+		if err != nil {
+			return 0, fmt.Errorf("purge user error: %w", err)
+		}
+	}
+
+	// Удаляем пользователя, его котики, ключи и привязки удаляются каскадно
+	err = s.userRepository.DeleteUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("purge user error: %w", err)
+	}
+
+	return len(cats), nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID int, apiKeyCreateRequest *entities.APIKeyCreateRequest) (*entities.APIKeyCreateResponse, error)
+	GetAllAPIKeys(ctx context.Context, userID int) ([]*entities.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID, apiKeyID int) error
+	VerifyAPIKey(ctx context.Context, key string) (*entities.APIKey, error)
+}
+
+type apiKeyServiceImpl struct {
+	apiKeyRepository repositories.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepository repositories.APIKeyRepository) APIKeyService {
+	return &apiKeyServiceImpl{apiKeyRepository: apiKeyRepository}
+}
+
+func (s *apiKeyServiceImpl) CreateAPIKey(ctx context.Context, userID int, apiKeyCreateRequest *entities.APIKeyCreateRequest) (*entities.APIKeyCreateResponse, error) {
+
+	// Проверяем название ключа
+	if apiKeyCreateRequest.Name == "" || len(apiKeyCreateRequest.Name) > 255 {
+		return nil, fmt.Errorf("create api key error: invalid name")
+	}
+
+	// Проверяем scopes
+	if len(apiKeyCreateRequest.Scopes) == 0 {
+		return nil, fmt.Errorf("create api key error: scopes required")
+	}
+	for _, scope := range apiKeyCreateRequest.Scopes {
+		if !slices.Contains(entities.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("create api key error: unknown scope %q", scope)
+		}
+	}
+
+	// Проверяем срок действия
+	if apiKeyCreateRequest.ExpiresAt != nil && apiKeyCreateRequest.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("create api key error: expiration date in the past")
+	}
+
+	// Генерируем ключ, в бд храним только его хеш и префикс для отображения
+	key := entities.APIKeyPrefix + utils.GenerateTokenID() + utils.GenerateTokenID()
+	apiKey := &entities.APIKey{
+		UserID:    userID,
+		Name:      apiKeyCreateRequest.Name,
+		Prefix:    key[:len(entities.APIKeyPrefix)+8],
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(apiKeyCreateRequest.Scopes))),
+		ExpiresAt: apiKeyCreateRequest.ExpiresAt,
+	}
+
+	// Сохраняем ключ
+	err := s.apiKeyRepository.CreateAPIKey(ctx, apiKey, utils.HashToken(key))
+	if err != nil {
+		return nil, fmt.Errorf("create api key error: %w", err)
+	}
+
+	return &entities.APIKeyCreateResponse{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *apiKeyServiceImpl) GetAllAPIKeys(ctx context.Context, userID int) ([]*entities.APIKey, error) {
+
+	// Получаем все ключи пользователя
+	apiKeys, err := s.apiKeyRepository.GetAllAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all api keys error: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+func (s *apiKeyServiceImpl) DeleteAPIKey(ctx context.Context, userID, apiKeyID int) error {
+
+	// Удаляем ключ
+	err := s.apiKeyRepository.DeleteAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return fmt.Errorf("delete api key error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *apiKeyServiceImpl) VerifyAPIKey(ctx context.Context, key string) (*entities.APIKey, error) {
+
+	// Проверяем формат ключа
+	if !strings.HasPrefix(key, entities.APIKeyPrefix) {
+		return nil, entities.ErrInvalidAPIKey
+	}
+
+	// Ищем ключ по хешу, недоступность бд не должна выглядеть как отозванный ключ
+	apiKey, err := s.apiKeyRepository.GetAPIKeyByHash(ctx, utils.HashToken(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, fmt.Errorf("verify api key error: %w", err)
+	}
+
+	// Проверяем срок действия
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		return nil, entities.ErrAPIKeyExpired
+	}
+
+	// Запоминаем время последнего использования (если не получилось - не критично)
+	_ = s.apiKeyRepository.UpdateAPIKeyLastUsed(ctx, apiKey.ID)
+
+	return apiKey, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+)
+
+type AuditLogger interface {
+	Log(ctx context.Context, event *entities.AuditEvent)
+	GetAuditEvents(ctx context.Context, filter *entities.AuditEventFilter) (*entities.AuditEventListResponse, error)
+}
+
+type auditLoggerImpl struct {
+	auditRepository repositories.AuditRepository
+	logger          zerolog.Logger
+	writeTimeout    time.Duration
+}
+
+func NewAuditLogger(auditRepository repositories.AuditRepository, logger zerolog.Logger, writeTimeout time.Duration) AuditLogger {
+	return &auditLoggerImpl{auditRepository: auditRepository, logger: logger, writeTimeout: writeTimeout}
+}
+
+// Запись события в журнал аудита.
+// Ошибка записи не прерывает основное действие, событие в этом случае уходит в лог приложения
+
+func (s *auditLoggerImpl) Log(ctx context.Context, event *entities.AuditEvent) {
+
+	// Событие записываем, даже если время запроса уже истекло или запрос отменен
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.writeTimeout)
+	defer cancel()
+
+	err := s.auditRepository.CreateAuditEvent(ctx, event)
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Int("actorID", event.ActorID).
+			Int("impersonatorID", event.ImpersonatorID).
+			Str("action", event.Action).
+			Str("targetType", event.TargetType).
+			Str("targetID", event.TargetID).
+			Str("outcome", event.Outcome).
+			Str("ip", event.IP).
+			Msg("failed to write audit event")
+	}
+}
+
+func (s *auditLoggerImpl) GetAuditEvents(ctx context.Context, filter *entities.AuditEventFilter) (*entities.AuditEventListResponse, error) {
+
+	// Проверяем количество событий
+	if filter.Limit == 0 {
+		filter.Limit = entities.AuditEventsDefaultLimit
+	}
+	if filter.Limit < 0 || filter.Limit > entities.AuditEventsMaxLimit {
+		return nil, fmt.Errorf("get audit events error: %w", entities.NewValidationError("limit must be between 1 and %d", entities.AuditEventsMaxLimit))
+	}
+
+	// Проверяем результат
+	if filter.Outcome != "" && filter.Outcome != entities.AuditOutcomeSuccess && filter.Outcome != entities.AuditOutcomeFailure {
+		return nil, fmt.Errorf("get audit events error: %w", entities.NewValidationError("invalid outcome"))
+	}
+
+	// Парсим границы периода (RFC 3339). Время события хранится в UTC без часового пояса,
+	// поэтому границы тоже приводим к UTC
+	if filter.From != "" {
+		from, err := time.Parse(time.RFC3339, filter.From)
+		if err != nil {
+			return nil, fmt.Errorf("get audit events error: %w", entities.NewValidationError("invalid from"))
+		}
+		filter.FromTime = from.UTC()
+	}
+	if filter.To != "" {
+		to, err := time.Parse(time.RFC3339, filter.To)
+		if err != nil {
+			return nil, fmt.Errorf("get audit events error: %w", entities.NewValidationError("invalid to"))
+		}
+		filter.ToTime = to.UTC()
+	}
+
+	// Проверяем курсор
+	if filter.BeforeID < 0 {
+		return nil, fmt.Errorf("get audit events error: %w", entities.NewValidationError("invalid before_id"))
+	}
+
+	// Получаем события
+	events, err := s.auditRepository.GetAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get audit events error: %w", err)
+	}
+
+	// Следующая страница начинается после последнего события
+	response := &entities.AuditEventListResponse{Events: events}
+	if len(events) == filter.Limit {
+		response.NextBeforeID = events[len(events)-1].ID
+	}
+
+	return response, nil
+}
+
+// Событие аудита. Результат определяется по ошибке действия, текст ошибки сохраняется в details.
+// При имперсонации actorID - пользователь, от имени которого выполнено действие, администратор сохраняется в impersonator_id
+
+func newAuditEvent(actorID int, clientInfo *entities.ClientInfo, action, targetType, targetID string, err error) *entities.AuditEvent {
+	event := &entities.AuditEvent{
+		ActorID:        actorID,
+		ImpersonatorID: clientInfo.ImpersonatorID,
+		Action:         action,
+		TargetType:     targetType,
+		TargetID:       targetID,
+		Outcome:        entities.AuditOutcomeSuccess,
+		IP:             clientInfo.IP,
+		UserAgent:      clientInfo.UserAgent,
+	}
+	if err != nil {
+		event.Outcome = entities.AuditOutcomeFailure
+		event.Details = err.Error()
+	}
+
+	return event
+}
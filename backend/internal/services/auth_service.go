@@ -2,8 +2,15 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/pkg/mailer"
 	"github.com/unwelcome/iqjtest/pkg/utils"
+	"net/url"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/unwelcome/iqjtest/internal/entities"
@@ -11,135 +18,1100 @@ import (
 )
 
 type AuthService interface {
-	RegistrationUser(ctx context.Context, userCreate *entities.UserCreateRequest) (*entities.AuthResponse, error)
-	LoginUser(ctx context.Context, userLogin *entities.UserLoginRequest) (*entities.AuthResponse, error)
-	RefreshToken(ctx context.Context, refreshToken string) (*entities.TokenPair, error)
-	DeleteRefreshToken(ctx context.Context, userID int, refreshToken string) error
-	DeleteUser(ctx context.Context, userID int) error
+	RegistrationUser(ctx context.Context, userCreate *entities.UserCreateRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error)
+	LoginUser(ctx context.Context, userLogin *entities.UserLoginRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error)
+	LoginUserTwoFactor(ctx context.Context, twoFactorLoginRequest *entities.TwoFactorLoginRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error)
+	LoginUserOIDC(ctx context.Context, callback *entities.OIDCCallbackRequest, stateCookie string, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string, clientInfo *entities.ClientInfo) (*entities.TokenPair, error)
+	VerifyAccessToken(ctx context.Context, accessToken string) (*entities.TokenClaims, error)
+	DeleteRefreshToken(ctx context.Context, accessTokenClaims *entities.TokenClaims, refreshToken string, clientInfo *entities.ClientInfo) (bool, error)
+	GetAllSessions(ctx context.Context, accessTokenClaims *entities.TokenClaims) ([]*entities.Session, error)
+	DeleteSession(ctx context.Context, userID int, sessionID string) error
+	DeleteOtherSessions(ctx context.Context, accessTokenClaims *entities.TokenClaims) error
+	UpdateUserPassword(ctx context.Context, accessTokenClaims *entities.TokenClaims, userUpdatePasswordRequest *entities.UserUpdatePasswordRequest, clientInfo *entities.ClientInfo) (*entities.TokenPair, error)
+	UpdateUserRole(ctx context.Context, actorID, userID int, userUpdateRoleRequest *entities.UserUpdateRoleRequest, clientInfo *entities.ClientInfo) error
+	RequestPasswordReset(ctx context.Context, passwordResetRequest *entities.PasswordResetRequest) error
+	ConfirmPasswordReset(ctx context.Context, passwordResetConfirmRequest *entities.PasswordResetConfirmRequest, clientInfo *entities.ClientInfo) error
+	DeleteUser(ctx context.Context, actorID, userID int, clientInfo *entities.ClientInfo) (*entities.UserDeleteResponse, error)
+	ImpersonateUser(ctx context.Context, actorID, userID int, clientInfo *entities.ClientInfo) (*entities.ImpersonationResponse, error)
+	GetJWKS() *entities.JWKS
 }
 
-type authServiceImpl struct {
-	userService     UserService
-	tokenRepository repositories.AuthRepository
+// Количество попыток ввода кода 2FA для одного challenge токена
+const mfaChallengeMaxAttempts = 5
 
-	secretKey            string
-	accessTokenLifetime  time.Duration
-	refreshTokenLifetime time.Duration
+type authServiceImpl struct {
+	userService      UserService
+	emailService     EmailService
+	twoFactorService TwoFactorService
+	oidcService      OIDCService
+	auditLogger      AuditLogger
+	tokenRepository  repositories.AuthRepository
+	mailer           mailer.Mailer
+	logger           zerolog.Logger
 
-	tokenID int
+	keyring                    *utils.TokenKeyring
+	accessTokenLifetime        time.Duration
+	refreshTokenLifetime       time.Duration
+	impersonationTokenLifetime time.Duration
+	registrationMode           string
+	accountDeletionGracePeriod time.Duration
+	loginProtection            *entities.LoginProtectionPolicy
+	mfaChallengeLifetime       time.Duration
+	passwordResetTokenLifetime time.Duration
+	passwordResetURL           string
 }
 
 func NewAuthService(
 	userService UserService,
+	emailService EmailService,
+	twoFactorService TwoFactorService,
+	oidcService OIDCService,
+	auditLogger AuditLogger,
 	tokenRepository repositories.AuthRepository,
-	secretKey string,
+	mailer mailer.Mailer,
+	logger zerolog.Logger,
+	keyring *utils.TokenKeyring,
 	accessTokenLifetime time.Duration,
 	refreshTokenLifetime time.Duration,
+	impersonationTokenLifetime time.Duration,
+	registrationMode string,
+	accountDeletionGracePeriod time.Duration,
+	loginProtection *entities.LoginProtectionPolicy,
+	mfaChallengeLifetime time.Duration,
+	passwordResetTokenLifetime time.Duration,
+	passwordResetURL string,
 ) AuthService {
 	return &authServiceImpl{
-		userService:     userService,
-		tokenRepository: tokenRepository,
+		userService:      userService,
+		emailService:     emailService,
+		twoFactorService: twoFactorService,
+		oidcService:      oidcService,
+		auditLogger:      auditLogger,
+		tokenRepository:  tokenRepository,
+		mailer:           mailer,
+		logger:           logger,
 
-		secretKey:            secretKey,
-		accessTokenLifetime:  accessTokenLifetime,
-		refreshTokenLifetime: refreshTokenLifetime,
-
-		tokenID: 1,
+		keyring:                    keyring,
+		accessTokenLifetime:        accessTokenLifetime,
+		refreshTokenLifetime:       refreshTokenLifetime,
+		impersonationTokenLifetime: impersonationTokenLifetime,
+		registrationMode:           registrationMode,
+		accountDeletionGracePeriod: accountDeletionGracePeriod,
+		loginProtection:            loginProtection,
+		mfaChallengeLifetime:       mfaChallengeLifetime,
+		passwordResetTokenLifetime: passwordResetTokenLifetime,
+		passwordResetURL:           passwordResetURL,
 	}
 }
 
-func (s *authServiceImpl) RegistrationUser(ctx context.Context, userCreate *entities.UserCreateRequest) (*entities.AuthResponse, error) {
+func (s *authServiceImpl) RegistrationUser(ctx context.Context, userCreate *entities.UserCreateRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error) {
+
+	// Проверяем режим регистрации
+	err := s.checkRegistrationMode(userCreate)
+	if err != nil {
+		s.auditLogger.Log(ctx, newAuditEvent(0, clientInfo, entities.AuditActionRegister, entities.AuditTargetLogin, userCreate.Login, err))
+		return nil, err
+	}
 
-	// Создаем пользователя
+	// Создаем пользователя (код приглашения, если передан, используется при создании)
 	userID, err := s.userService.CreateUser(ctx, userCreate)
 	if err != nil {
+		s.auditLogger.Log(ctx, newAuditEvent(0, clientInfo, entities.AuditActionRegister, entities.AuditTargetLogin, userCreate.Login, err))
 		return nil, err
 	}
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionRegister, entities.AuditTargetUser, strconv.Itoa(userID), nil))
 
-	// Генерируем пару access и refresh токенов
-	tokenPair, err := utils.CreateTokens(userID, s.secretKey, s.accessTokenLifetime, s.refreshTokenLifetime, &s.tokenID)
+	// Отправляем письмо для подтверждения email (если не получилось - пользователь сможет запросить письмо повторно)
+	if userCreate.Email != "" {
+		err = s.emailService.SendVerificationEmail(ctx, userID)
+		if err != nil {
+			s.logger.Error().Err(err).Int("userID", userID).Msg("failed to send verification email")
+		}
+	}
+
+	// Создаем сессию и генерируем пару access и refresh токенов
+	tokenPair, err := s.startSession(ctx, userID, clientInfo)
 	if err != nil {
 		return nil, fmt.Errorf("create user error: %w", err)
 	}
 
-	// Сохраняем refresh токен в кеш (если не получилось - не критично)
-	_ = s.tokenRepository.AddToken(ctx, userID, tokenPair.RefreshToken, entities.RefreshTokenType, s.refreshTokenLifetime)
-
 	return &entities.AuthResponse{TokenPair: tokenPair, UserID: userID}, nil
 }
 
-func (s *authServiceImpl) LoginUser(ctx context.Context, userLogin *entities.UserLoginRequest) (*entities.AuthResponse, error) {
+// Регистрация закрыта - запрещена всем, только по приглашениям - требует код приглашения
+
+func (s *authServiceImpl) checkRegistrationMode(userCreate *entities.UserCreateRequest) error {
+	switch s.registrationMode {
+	case entities.RegistrationModeClosed:
+		return entities.ErrRegistrationClosed
+	case entities.RegistrationModeInviteOnly:
+		if userCreate.InvitationCode == "" {
+			return entities.ErrInvitationRequired
+		}
+	}
+	return nil
+}
+
+func (s *authServiceImpl) LoginUser(ctx context.Context, userLogin *entities.UserLoginRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error) {
+
+	// Проверяем, не заблокирован ли вход для логина или IP адреса
+	err := s.checkLoginLock(ctx, userLogin.Login, clientInfo.IP)
+	if err != nil {
+		s.auditLogin(ctx, entities.AuditActionLogin, 0, userLogin.Login, clientInfo, nil, err)
+		return nil, err
+	}
 
 	// Проверяем, есть ли пользователь с таким логином в системе и получаем его ID
 	userID, err := s.userService.LoginUser(ctx, userLogin)
+	if errors.Is(err, entities.ErrInvalidCredentials) {
+		err = s.registerFailedLogin(ctx, userLogin.Login, clientInfo, err)
+		s.auditLogin(ctx, entities.AuditActionLogin, 0, userLogin.Login, clientInfo, nil, err)
+		return nil, err
+	} else if err != nil {
+		s.auditLogin(ctx, entities.AuditActionLogin, 0, userLogin.Login, clientInfo, nil, err)
+		return nil, err
+	}
+
+	// Выдаем токены или challenge токен 2FA
+	authResponse, err := s.completeLogin(ctx, userID, clientInfo)
+	s.auditLogin(ctx, entities.AuditActionLogin, userID, userLogin.Login, clientInfo, authResponse, err)
+
+	// Сбрасываем счетчик неудачных попыток для логина (если не получилось - не критично).
+	// При включенной 2FA счетчик сбрасывается только после проверки второго фактора
+	if err == nil && !authResponse.MFARequired {
+		_ = s.tokenRepository.ResetFailedLoginAttempts(ctx, "login", userLogin.Login)
+	}
+
+	return authResponse, err
+}
+
+func (s *authServiceImpl) LoginUserOIDC(ctx context.Context, callback *entities.OIDCCallbackRequest, stateCookie string, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error) {
+
+	// Проверяем ответ провайдера и получаем пользователя (привязанного, найденного по email или нового)
+	userID, err := s.oidcService.CompleteLogin(ctx, callback, stateCookie)
 	if err != nil {
+		s.auditLogin(ctx, entities.AuditActionLoginOIDC, 0, "", clientInfo, nil, err)
 		return nil, err
 	}
 
-	// Генерируем токены
-	tokenPair, err := utils.CreateTokens(userID, s.secretKey, s.accessTokenLifetime, s.refreshTokenLifetime, &s.tokenID)
+	// Дальше вход такой же, как по паролю: 2FA пользователя тоже требуется
+	authResponse, err := s.completeLogin(ctx, userID, clientInfo)
+	s.auditLogin(ctx, entities.AuditActionLoginOIDC, userID, "", clientInfo, authResponse, err)
+
+	return authResponse, err
+}
+
+func (s *authServiceImpl) LoginUserTwoFactor(ctx context.Context, twoFactorLoginRequest *entities.TwoFactorLoginRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error) {
+
+	// Проверяем второй фактор и записываем результат в журнал аудита
+	authResponse, userID, err := s.loginUserTwoFactor(ctx, twoFactorLoginRequest, clientInfo)
+	s.auditLogin(ctx, entities.AuditActionLoginTwoFactor, userID, "", clientInfo, authResponse, err)
+
+	return authResponse, err
+}
+
+func (s *authServiceImpl) loginUserTwoFactor(ctx context.Context, twoFactorLoginRequest *entities.TwoFactorLoginRequest, clientInfo *entities.ClientInfo) (*entities.AuthResponse, int, error) {
+
+	// Парсим challenge токен
+	tokenClaims, err := utils.ParseToken(twoFactorLoginRequest.ChallengeToken, s.keyring)
 	if err != nil {
-		return nil, fmt.Errorf("login user error: %w", err)
+		return nil, 0, fmt.Errorf("login user error: %w", err)
 	}
 
-	// Сохраняем refresh токен в кеш (если не получилось - не критично)
-	_ = s.tokenRepository.AddToken(ctx, userID, tokenPair.RefreshToken, entities.RefreshTokenType, s.refreshTokenLifetime)
+	// Проверяем тип токена
+	if tokenClaims.Type != entities.MFAChallengeTokenType {
+		return nil, 0, fmt.Errorf("login user error: invalid token type")
+	}
 
-	return &entities.AuthResponse{TokenPair: tokenPair, UserID: userID}, nil
+	// Challenge токен одноразовый: после успешного входа или исчерпания попыток он отзывается
+	revoked, err := s.tokenRepository.CheckRevokedAccessToken(ctx, tokenClaims.ID)
+	if err != nil {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: %w", err)
+	}
+	if revoked {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: challenge token already used")
+	}
+
+	// Получаем логин пользователя, неверные коды учитываются в тех же счетчиках, что и неверные пароли.
+	// Пользователь может быть помечен на удаление: вход отменяет удаление
+	login, err := s.userService.GetUserLogin(ctx, tokenClaims.UserID)
+	if err != nil {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: %w", err)
+	}
+
+	// Проверяем, не заблокирован ли вход для логина или IP адреса
+	err = s.checkLoginLock(ctx, login, clientInfo.IP)
+	if err != nil {
+		return nil, tokenClaims.UserID, err
+	}
+
+	// Ограничиваем перебор кодов в рамках одного challenge токена. Попытка учитывается до проверки кода,
+	// чтобы параллельные запросы не могли превысить лимит
+	attempts, err := s.tokenRepository.AddFailedLoginAttempt(ctx, "mfa", tokenClaims.ID, s.mfaChallengeLifetime)
+	if err != nil {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: %w", err)
+	}
+	if attempts > mfaChallengeMaxAttempts {
+		_ = s.tokenRepository.RevokeAccessToken(ctx, tokenClaims.ID, time.Until(tokenClaims.ExpiresAt.Time))
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: too many invalid codes, log in again")
+	}
+
+	// Проверяем код из приложения или код восстановления
+	ok, err := s.twoFactorService.VerifyTwoFactorCode(ctx, tokenClaims.UserID, twoFactorLoginRequest.Code)
+	if err != nil {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: %w", err)
+	}
+	if !ok {
+		// Последняя попытка исчерпывает challenge токен
+		if attempts == mfaChallengeMaxAttempts {
+			_ = s.tokenRepository.RevokeAccessToken(ctx, tokenClaims.ID, time.Until(tokenClaims.ExpiresAt.Time))
+		}
+		return nil, tokenClaims.UserID, s.registerFailedLogin(ctx, login, clientInfo, fmt.Errorf("login user error: %w", entities.ErrInvalidTwoFactorCode))
+	}
+
+	// Отзываем challenge токен
+	err = s.tokenRepository.RevokeAccessToken(ctx, tokenClaims.ID, time.Until(tokenClaims.ExpiresAt.Time))
+	if err != nil {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: %w", err)
+	}
+
+	// Второй фактор подтвержден, сбрасываем счетчик неудачных попыток для логина (если не получилось - не критично)
+	_ = s.tokenRepository.ResetFailedLoginAttempts(ctx, "login", login)
+
+	// Вход отменяет удаление аккаунта
+	err = s.restoreUser(ctx, tokenClaims.UserID, clientInfo)
+	if err != nil {
+		return nil, tokenClaims.UserID, err
+	}
+
+	// Создаем сессию и генерируем токены
+	tokenPair, err := s.startSession(ctx, tokenClaims.UserID, clientInfo)
+	if err != nil {
+		return nil, tokenClaims.UserID, fmt.Errorf("login user error: %w", err)
+	}
+
+	return &entities.AuthResponse{TokenPair: tokenPair, UserID: tokenClaims.UserID}, tokenClaims.UserID, nil
 }
 
-func (s *authServiceImpl) RefreshToken(ctx context.Context, refreshToken string) (*entities.TokenPair, error) {
+func (s *authServiceImpl) RefreshToken(ctx context.Context, refreshToken string, clientInfo *entities.ClientInfo) (*entities.TokenPair, error) {
+
+	// Обновляем токены и записываем результат в журнал аудита
+	tokenPair, tokenClaims, err := s.refreshToken(ctx, refreshToken, clientInfo)
+	event := newAuditEvent(0, clientInfo, entities.AuditActionRefresh, "", "", err)
+	if tokenClaims != nil {
+		event.ActorID = tokenClaims.UserID
+		event.TargetType = entities.AuditTargetSession
+		event.TargetID = tokenClaims.SessionID
+	}
+	s.auditLogger.Log(ctx, event)
+
+	return tokenPair, err
+}
+
+func (s *authServiceImpl) refreshToken(ctx context.Context, refreshToken string, clientInfo *entities.ClientInfo) (*entities.TokenPair, *entities.TokenClaims, error) {
 
 	// Парсим refresh токен
-	tokenClaims, err := utils.ParseToken(refreshToken, s.secretKey)
+	tokenClaims, err := utils.ParseToken(refreshToken, s.keyring)
 	if err != nil {
-		return nil, fmt.Errorf("refresh tokens error: %w", err)
+		return nil, nil, fmt.Errorf("refresh tokens error: %w: %w", entities.ErrInvalidRefreshToken, err)
 	}
 
 	// Проверяем тип токена
 	if tokenClaims.Type != entities.RefreshTokenType {
-		return nil, fmt.Errorf("refresh tokens error: invalid token type")
+		return nil, nil, fmt.Errorf("refresh tokens error: %w: invalid token type", entities.ErrInvalidRefreshToken)
+	}
+
+	// Проверяем, что сессия токена не завершена: refresh токены завершенной сессии не обновляются
+	revoked, err := s.tokenRepository.CheckRevokedSession(ctx, tokenClaims.SessionID)
+	if err != nil {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
+	}
+	if revoked {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", entities.ErrInvalidRefreshToken)
+	}
+	session, _, _, err := s.tokenRepository.GetSession(ctx, tokenClaims.UserID, tokenClaims.SessionID)
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", entities.ErrInvalidRefreshToken)
+	} else if err != nil {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
 	}
 
-	// Создаем новую пару токенов
-	tokenPair, err := utils.CreateTokens(tokenClaims.UserID, s.secretKey, s.accessTokenLifetime, s.refreshTokenLifetime, &s.tokenID)
+	// Получаем актуальную роль пользователя, изменение роли вступает в силу при обновлении токенов
+	user, err := s.userService.GetUserByID(ctx, tokenClaims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w: user not found", entities.ErrInvalidRefreshToken)
+	} else if err != nil {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
+	}
+
+	// Создаем новую пару токенов в рамках той же сессии и того же семейства
+	subject := &entities.TokenSubject{UserID: tokenClaims.UserID, SessionID: tokenClaims.SessionID, FamilyID: tokenClaims.FamilyID, Role: user.Role}
+	tokenPair, err := utils.CreateTokens(subject, s.keyring, s.accessTokenLifetime, s.refreshTokenLifetime)
 	if err != nil {
-		return nil, fmt.Errorf("refresh tokens error: %w", err)
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
 	}
 
-	// Заменяем старый refresh токен на новый
-	err = s.tokenRepository.ReplaceToken(ctx, tokenClaims.UserID, refreshToken, tokenPair.RefreshToken, entities.RefreshTokenType, s.refreshTokenLifetime)
+	// Запоминаем ID нового access токена для возможности его отзыва. Без этого токен нельзя будет отозвать,
+	// поэтому при ошибке токены не выдаются, а старый refresh токен остается действительным
+	err = s.tokenRepository.AddToken(ctx, tokenClaims.UserID, tokenPair.AccessTokenID, entities.AccessTokenType, s.accessTokenLifetime)
 	if err != nil {
-		return nil, fmt.Errorf("refresh tokens error: %w", err)
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
 	}
 
-	return tokenPair, nil
+	// Заменяем текущий токен семейства и старый refresh токен на новый одной операцией: если токен отозван
+	// или замена не удалась, семейство не меняется и клиент может повторить запрос со старым токеном
+	reused, err := s.tokenRepository.RotateTokenFamily(ctx, tokenClaims.UserID, tokenClaims.FamilyID, tokenClaims.ID, tokenPair.RefreshTokenID, refreshToken, tokenPair.RefreshToken, s.refreshTokenLifetime)
+	if err != nil {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
+	}
+
+	// Токен уже был использован ранее - вероятно, он украден, отзываем все семейство
+	if reused {
+		s.revokeTokenFamily(ctx, tokenClaims, clientInfo)
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w: token reuse detected, session revoked", entities.ErrInvalidRefreshToken)
+	}
+
+	// Обновляем данные сессии, семейство токенов сессии не меняется
+	session.UserAgent = clientInfo.UserAgent
+	session.IP = clientInfo.IP
+	session.LastRefreshAt = time.Now()
+	err = s.tokenRepository.SaveSession(ctx, tokenClaims.UserID, session, tokenPair.RefreshToken, tokenClaims.FamilyID, s.refreshTokenLifetime)
+	if err != nil {
+		return nil, tokenClaims, fmt.Errorf("refresh tokens error: %w", err)
+	}
+
+	return tokenPair, tokenClaims, nil
+}
+
+func (s *authServiceImpl) VerifyAccessToken(ctx context.Context, accessToken string) (*entities.TokenClaims, error) {
+
+	// Парсим токен
+	tokenClaims, err := utils.ParseToken(accessToken, s.keyring)
+	if err != nil {
+		return nil, fmt.Errorf("parse token error: %w", err)
+	}
+
+	// Проверяем тип токена
+	if tokenClaims.Type != entities.AccessTokenType {
+		return nil, fmt.Errorf("invalid token type")
+	}
+
+	// Проверяем, что токен не был отозван
+	revoked, err := s.tokenRepository.CheckRevokedAccessToken(ctx, tokenClaims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("verify token error: %w", err)
+	}
+	if revoked {
+		return nil, fmt.Errorf("token revoked")
+	}
+
+	// Проверяем, что сессия токена не была завершена
+	revoked, err = s.tokenRepository.CheckRevokedSession(ctx, tokenClaims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("verify token error: %w", err)
+	}
+	if revoked {
+		return nil, fmt.Errorf("session revoked")
+	}
+
+	return tokenClaims, nil
+}
+
+// Выход из текущей сессии. Возвращает true, если текущий access токен отозван (даже если дальше произошла ошибка)
+
+func (s *authServiceImpl) DeleteRefreshToken(ctx context.Context, accessTokenClaims *entities.TokenClaims, refreshToken string, clientInfo *entities.ClientInfo) (bool, error) {
+
+	// Отзываем токены сессии и записываем результат в журнал аудита
+	revoked, err := s.deleteRefreshToken(ctx, accessTokenClaims, refreshToken)
+	s.auditLogger.Log(ctx, newAuditEvent(accessTokenClaims.UserID, clientInfo, entities.AuditActionLogout, entities.AuditTargetSession, accessTokenClaims.SessionID, err))
+
+	return revoked, err
+}
+
+func (s *authServiceImpl) deleteRefreshToken(ctx context.Context, accessTokenClaims *entities.TokenClaims, refreshToken string) (bool, error) {
+
+	// Отзываем текущий access токен в первую очередь и независимо от переданного refresh токена
+	err := s.tokenRepository.RevokeAccessToken(ctx, accessTokenClaims.ID, time.Until(accessTokenClaims.ExpiresAt.Time))
+	if err != nil {
+		return false, fmt.Errorf("delete refresh token error: %w", err)
+	}
+
+	// Завершаем текущую сессию: отзываем все ее access токены, в том числе выданные до обновления, и семейство refresh токенов.
+	// Если записи сессии нет, отзываем сессию и семейство из claims напрямую
+	err = s.revokeSession(ctx, accessTokenClaims.UserID, accessTokenClaims.SessionID)
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		err = s.tokenRepository.RevokeSession(ctx, accessTokenClaims.SessionID, s.accessTokenLifetime)
+		if err == nil {
+			err = s.tokenRepository.DeleteTokenFamily(ctx, accessTokenClaims.FamilyID)
+		}
+	}
+	if err != nil {
+		return true, fmt.Errorf("delete refresh token error: %w", err)
+	}
+
+	// Переданный refresh токен удаляем, только если он выдан для текущей сессии. Токен другой сессии не трогаем
+	refreshTokenClaims, err := utils.ParseToken(refreshToken, s.keyring)
+	if err != nil || refreshTokenClaims.Type != entities.RefreshTokenType ||
+		refreshTokenClaims.UserID != accessTokenClaims.UserID || refreshTokenClaims.SessionID != accessTokenClaims.SessionID {
+		return true, fmt.Errorf("delete refresh token error: %w", entities.ErrInvalidRefreshToken)
+	}
+
+	// Токен мог уже быть удален вместе с сессией или истечь - не критично, семейство сессии уже удалено
+	_ = s.tokenRepository.DeleteToken(ctx, accessTokenClaims.UserID, refreshToken, entities.RefreshTokenType)
+
+	return true, nil
 }
 
-func (s *authServiceImpl) DeleteRefreshToken(ctx context.Context, userID int, refreshToken string) error {
+func (s *authServiceImpl) GetAllSessions(ctx context.Context, accessTokenClaims *entities.TokenClaims) ([]*entities.Session, error) {
 
-	// Удаляем токен
-	err := s.tokenRepository.DeleteToken(ctx, userID, refreshToken, entities.RefreshTokenType)
+	// Получаем все сессии пользователя
+	sessions, err := s.tokenRepository.GetAllSessions(ctx, accessTokenClaims.UserID)
 	if err != nil {
-		return fmt.Errorf("delete refresh token error: %w", err)
+		return nil, fmt.Errorf("get all sessions error: %w", err)
+	}
+
+	// Отмечаем текущую сессию
+	for _, session := range sessions {
+		session.IsCurrent = session.ID == accessTokenClaims.SessionID
+	}
+
+	// Сортируем сессии по времени последнего обновления
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastRefreshAt.After(sessions[j].LastRefreshAt)
+	})
+
+	return sessions, nil
+}
+
+func (s *authServiceImpl) DeleteSession(ctx context.Context, userID int, sessionID string) error {
+
+	// Завершаем сессию
+	err := s.revokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("delete session error: %w", err)
 	}
 
 	return nil
 }
 
-func (s *authServiceImpl) DeleteUser(ctx context.Context, userID int) error {
+func (s *authServiceImpl) DeleteOtherSessions(ctx context.Context, accessTokenClaims *entities.TokenClaims) error {
+
+	// Получаем все сессии пользователя
+	sessions, err := s.tokenRepository.GetAllSessions(ctx, accessTokenClaims.UserID)
+	if err != nil {
+		return fmt.Errorf("delete other sessions error: %w", err)
+	}
+
+	// Завершаем все сессии, кроме текущей
+	for _, session := range sessions {
+		if session.ID == accessTokenClaims.SessionID {
+			continue
+		}
+
+		err = s.revokeSession(ctx, accessTokenClaims.UserID, session.ID)
+		if err != nil {
+			return fmt.Errorf("delete other sessions error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *authServiceImpl) UpdateUserPassword(ctx context.Context, accessTokenClaims *entities.TokenClaims, userUpdatePasswordRequest *entities.UserUpdatePasswordRequest, clientInfo *entities.ClientInfo) (*entities.TokenPair, error) {
+
+	// Меняем пароль и записываем результат в журнал аудита
+	tokenPair, err := s.updateUserPassword(ctx, accessTokenClaims, userUpdatePasswordRequest, clientInfo)
+	userID := accessTokenClaims.UserID
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionPasswordChange, entities.AuditTargetUser, strconv.Itoa(userID), err))
+
+	return tokenPair, err
+}
+
+func (s *authServiceImpl) updateUserPassword(ctx context.Context, accessTokenClaims *entities.TokenClaims, userUpdatePasswordRequest *entities.UserUpdatePasswordRequest, clientInfo *entities.ClientInfo) (*entities.TokenPair, error) {
+	userID := accessTokenClaims.UserID
+
+	// Получаем логин пользователя, неверные текущие пароли учитываются в тех же счетчиках, что и неверные пароли при входе
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("update user password error: %w", err)
+	}
 
-	// Удаляем пользователя из бд
-	err := s.userService.DeleteUser(ctx, userID)
+	// Проверяем, не заблокирован ли вход для логина или IP адреса, иначе украденный токен позволял бы подбирать пароль
+	err = s.checkLoginLock(ctx, user.Login, clientInfo.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем текущий пароль, одного access токена для смены пароля недостаточно
+	err = s.userService.CheckUserPassword(ctx, userID, userUpdatePasswordRequest.CurrentPassword)
+	if errors.Is(err, entities.ErrInvalidCredentials) {
+		return nil, s.registerFailedLogin(ctx, user.Login, clientInfo, fmt.Errorf("update user password error: %w", err))
+	} else if err != nil {
+		return nil, fmt.Errorf("update user password error: %w", err)
+	}
+
+	// Текущий пароль подтвержден, сбрасываем счетчик неудачных попыток для логина (если не получилось - не критично)
+	_ = s.tokenRepository.ResetFailedLoginAttempts(ctx, "login", user.Login)
+
+	// Обновляем пароль
+	err = s.userService.UpdateUserPassword(ctx, userID, userUpdatePasswordRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Получаем все сессии пользователя
+	sessions, err := s.tokenRepository.GetAllSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("update user password error: %w", err)
+	}
+
+	// Завершаем все сессии, кроме текущей
+	currentSession := &entities.Session{ID: accessTokenClaims.SessionID, CreatedAt: time.Now()}
+	for _, session := range sessions {
+		if session.ID == accessTokenClaims.SessionID {
+			currentSession = session
+			continue
+		}
+
+		err = s.revokeSession(ctx, userID, session.ID)
+		if err != nil {
+			return nil, fmt.Errorf("update user password error: %w", err)
+		}
+	}
+
+	// Отзываем все выданные access и refresh токены, включая токены текущей сессии
+	err = s.revokeAllTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("update user password error: %w", err)
+	}
+	_ = s.tokenRepository.DeleteTokenFamily(ctx, accessTokenClaims.FamilyID)
+
+	// Выдаем новые токены в рамках текущей сессии
+	currentSession.UserAgent = clientInfo.UserAgent
+	currentSession.IP = clientInfo.IP
+	tokenPair, err := s.issueSessionTokens(ctx, userID, currentSession)
+	if err != nil {
+		return nil, fmt.Errorf("update user password error: %w", err)
+	}
+
+	return tokenPair, nil
+}
+
+func (s *authServiceImpl) UpdateUserRole(ctx context.Context, actorID, userID int, userUpdateRoleRequest *entities.UserUpdateRoleRequest, clientInfo *entities.ClientInfo) error {
+
+	// Меняем роль и записываем результат в журнал аудита
+	err := s.updateUserRole(ctx, actorID, userID, userUpdateRoleRequest)
+	event := newAuditEvent(actorID, clientInfo, entities.AuditActionRoleChange, entities.AuditTargetUser, strconv.Itoa(userID), err)
+	if err == nil {
+		event.Details = "role: " + userUpdateRoleRequest.Role
+	}
+	s.auditLogger.Log(ctx, event)
+
+	return err
+}
+
+func (s *authServiceImpl) updateUserRole(ctx context.Context, actorID, userID int, userUpdateRoleRequest *entities.UserUpdateRoleRequest) error {
+
+	// Администратор не может изменить свою роль, иначе можно остаться без администраторов
+	if actorID == userID {
+		return fmt.Errorf("update user role error: can't change own role")
+	}
+
+	// Обновляем роль
+	err := s.userService.UpdateUserRole(ctx, userID, userUpdateRoleRequest)
 	if err != nil {
 		return err
 	}
 
-	// Удаляем все токены пользователя
-	err = s.tokenRepository.DeleteAllTokens(ctx, userID)
+	// Отзываем токены пользователя, в них записана старая роль
+	err = s.revokeAllTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("update user role error: %w", err)
+	}
+
+	// Логируем изменение прав
+	s.logger.Info().Int("actorID", actorID).Int("userID", userID).Str("role", userUpdateRoleRequest.Role).Msg("user role changed")
+
+	return nil
+}
+
+func (s *authServiceImpl) RequestPasswordReset(ctx context.Context, passwordResetRequest *entities.PasswordResetRequest) error {
+
+	// Получаем пользователя. Если пользователя нет или у него нет подтвержденного email - ничего не делаем,
+	// ответ не должен выдавать существование логина
+	user, err := s.userService.GetUserByLogin(ctx, passwordResetRequest.Login)
+	if err != nil || user.Email == "" || !user.EmailVerified {
+		return nil
+	}
+
+	// Генерируем токен сброса, в кеше храним только его хеш
+	resetToken := utils.GenerateTokenID()
+	err = s.tokenRepository.SaveOneTimeToken(ctx, entities.PasswordResetTokenPurpose, utils.HashToken(resetToken), &entities.OneTimeToken{UserID: user.ID}, s.passwordResetTokenLifetime)
+	if err != nil {
+		return fmt.Errorf("request password reset error: %w", err)
+	}
+
+	// Формируем ссылку для сброса пароля
+	resetURL, err := url.Parse(s.passwordResetURL)
+	if err != nil {
+		return fmt.Errorf("request password reset error: %w", err)
+	}
+	query := resetURL.Query()
+	query.Set("token", resetToken)
+	resetURL.RawQuery = query.Encode()
+
+	// Отправляем письмо (ошибку только логируем, чтобы ответ не отличался)
+	err = s.mailer.Send(ctx, &entities.Mail{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Для сброса пароля перейдите по ссылке: %s\nСсылка действительна %d минут. Если вы не запрашивали сброс пароля, проигнорируйте это письмо.",
+			resetURL.String(), int(s.passwordResetTokenLifetime.Minutes())),
+	})
 	if err != nil {
-		return fmt.Errorf("delete all user tokens error: %w", err)
+		s.logger.Error().Err(err).Int("userID", user.ID).Msg("failed to send password reset mail")
 	}
 
 	return nil
 }
+
+func (s *authServiceImpl) ConfirmPasswordReset(ctx context.Context, passwordResetConfirmRequest *entities.PasswordResetConfirmRequest, clientInfo *entities.ClientInfo) error {
+
+	// Устанавливаем новый пароль и записываем результат в журнал аудита
+	userID, err := s.confirmPasswordReset(ctx, passwordResetConfirmRequest)
+	// Действие выполняет владелец токена сброса, он не авторизован
+	event := newAuditEvent(0, clientInfo, entities.AuditActionPasswordReset, "", "", err)
+	if userID != 0 {
+		event.TargetType = entities.AuditTargetUser
+		event.TargetID = strconv.Itoa(userID)
+	}
+	s.auditLogger.Log(ctx, event)
+
+	return err
+}
+
+func (s *authServiceImpl) confirmPasswordReset(ctx context.Context, passwordResetConfirmRequest *entities.PasswordResetConfirmRequest) (int, error) {
+
+	tokenHash := utils.HashToken(passwordResetConfirmRequest.Token)
+
+	// Проверяем новый пароль до использования токена, чтобы неподходящий пароль не сжигал токен
+	resetToken, err := s.tokenRepository.GetOneTimeToken(ctx, entities.PasswordResetTokenPurpose, tokenHash)
+	if err != nil {
+		return 0, fmt.Errorf("confirm password reset error: %w", err)
+	}
+	userID := resetToken.UserID
+	err = s.userService.ValidateUserPassword(ctx, userID, passwordResetConfirmRequest.Password)
+	if err != nil {
+		return userID, fmt.Errorf("confirm password reset error: %w", err)
+	}
+
+	// Используем токен сброса, повторно он не сработает
+	resetToken, err = s.tokenRepository.ConsumeOneTimeToken(ctx, entities.PasswordResetTokenPurpose, tokenHash)
+	if err != nil {
+		return userID, fmt.Errorf("confirm password reset error: %w", err)
+	}
+	userID = resetToken.UserID
+
+	// Обновляем пароль
+	err = s.userService.UpdateUserPassword(ctx, userID, &entities.UserUpdatePasswordRequest{Password: passwordResetConfirmRequest.Password})
+	if err != nil {
+		return userID, err
+	}
+
+	// Завершаем все сессии пользователя
+	err = s.revokeAllTokens(ctx, userID)
+	if err != nil {
+		return userID, fmt.Errorf("confirm password reset error: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (s *authServiceImpl) DeleteUser(ctx context.Context, actorID, userID int, clientInfo *entities.ClientInfo) (*entities.UserDeleteResponse, error) {
+
+	// Помечаем пользователя на удаление и записываем результат в журнал аудита
+	userDeleteResponse, err := s.deleteUser(ctx, actorID, userID)
+	s.auditLogger.Log(ctx, newAuditEvent(actorID, clientInfo, entities.AuditActionDeleteUser, entities.AuditTargetUser, strconv.Itoa(userID), err))
+
+	return userDeleteResponse, err
+}
+
+// Удаление пользователя откладывается на срок accountDeletionGracePeriod: до его окончания пользователь может
+// отменить удаление входом в аккаунт. Пользователя, его котиков и фото удаляет фоновая очистка
+
+func (s *authServiceImpl) deleteUser(ctx context.Context, actorID, userID int) (*entities.UserDeleteResponse, error) {
+
+	// Помечаем пользователя на удаление
+	userDeletion, err := s.userService.ScheduleUserDeletion(ctx, userID, actorID, s.accountDeletionGracePeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	// Отзываем все токены пользователя
+	err = s.revokeAllTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("delete all user tokens error: %w", err)
+	}
+
+	return &entities.UserDeleteResponse{ID: userID, PurgeAfter: userDeletion.PurgeAfter}, nil
+}
+
+func (s *authServiceImpl) ImpersonateUser(ctx context.Context, actorID, userID int, clientInfo *entities.ClientInfo) (*entities.ImpersonationResponse, error) {
+
+	// Выдаем токен имперсонации и записываем результат в журнал аудита
+	impersonationResponse, err := s.impersonateUser(ctx, actorID, userID)
+	s.auditLogger.Log(ctx, newAuditEvent(actorID, clientInfo, entities.AuditActionImpersonate, entities.AuditTargetUser, strconv.Itoa(userID), err))
+
+	return impersonationResponse, err
+}
+
+func (s *authServiceImpl) impersonateUser(ctx context.Context, actorID, userID int) (*entities.ImpersonationResponse, error) {
+
+	// Войти от своего имени можно и без имперсонации
+	if actorID == userID {
+		return nil, fmt.Errorf("impersonate user error: can't impersonate yourself")
+	}
+
+	// Получаем пользователя, токен выдается с его ролью
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate user error: %w", err)
+	}
+
+	// Имперсонация администратора дала бы доступ к его правам без его ведома
+	if entities.HasRole(user.Role, entities.RoleAdmin) {
+		return nil, fmt.Errorf("impersonate user error: can't impersonate admin")
+	}
+
+	// От имени пользователя, помеченного на удаление, войти нельзя
+	userDeletion, err := s.userService.GetUserDeletion(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate user error: %w", err)
+	}
+	if userDeletion != nil {
+		return nil, fmt.Errorf("impersonate user error: %w", entities.ErrAccountDeleted)
+	}
+
+	// Генерируем только access токен: без refresh токена сессию имперсонации нельзя продлить
+	subject := &entities.TokenSubject{
+		UserID:    userID,
+		SessionID: utils.GenerateTokenID(),
+		Role:      user.Role,
+		Actor:     &entities.TokenActor{UserID: actorID},
+	}
+	tokenID := utils.GenerateTokenID()
+	expiresAt := time.Now().Add(s.impersonationTokenLifetime)
+	accessToken, err := utils.GenerateToken(subject, s.keyring, entities.AccessTokenType, s.impersonationTokenLifetime, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate user error: %w", err)
+	}
+
+	// Запоминаем ID токена, чтобы он отзывался вместе с остальными токенами пользователя
+	err = s.tokenRepository.AddToken(ctx, userID, tokenID, entities.AccessTokenType, s.impersonationTokenLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate user error: %w", err)
+	}
+
+	return &entities.ImpersonationResponse{AccessToken: accessToken, ExpiresAt: expiresAt, UserID: userID, ActorID: actorID}, nil
+}
+
+func (s *authServiceImpl) GetJWKS() *entities.JWKS {
+	return s.keyring.JWKS()
+}
+
+// Завершение входа после проверки первого фактора.
+// Если включена 2FA - вместо токенов выдаем challenge токен для второго шага входа
+
+func (s *authServiceImpl) completeLogin(ctx context.Context, userID int, clientInfo *entities.ClientInfo) (*entities.AuthResponse, error) {
+
+	// В аккаунт, удаленный администратором, войти нельзя
+	_, err := s.checkUserDeletion(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactorEnabled, err := s.twoFactorService.IsTwoFactorEnabled(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("login user error: %w", err)
+	}
+	if twoFactorEnabled {
+		subject := &entities.TokenSubject{UserID: userID}
+		challengeToken, err := utils.GenerateToken(subject, s.keyring, entities.MFAChallengeTokenType, s.mfaChallengeLifetime, utils.GenerateTokenID())
+		if err != nil {
+			return nil, fmt.Errorf("login user error: %w", err)
+		}
+
+		return &entities.AuthResponse{UserID: userID, MFARequired: true, ChallengeToken: challengeToken}, nil
+	}
+
+	// Вход отменяет удаление аккаунта
+	err = s.restoreUser(ctx, userID, clientInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Создаем сессию и генерируем токены
+	tokenPair, err := s.startSession(ctx, userID, clientInfo)
+	if err != nil {
+		return nil, fmt.Errorf("login user error: %w", err)
+	}
+
+	return &entities.AuthResponse{TokenPair: tokenPair, UserID: userID}, nil
+}
+
+// Проверка пометки на удаление при входе. Удаление, запрошенное самим пользователем, отменяется входом,
+// в аккаунт, удаленный администратором или уже очищаемый, войти нельзя
+
+func (s *authServiceImpl) checkUserDeletion(ctx context.Context, userID int) (*entities.UserDeletion, error) {
+	userDeletion, err := s.userService.GetUserDeletion(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("login user error: %w", err)
+	}
+	if userDeletion != nil && (userDeletion.RequestedBy != userID || userDeletion.PurgeStartedAt != nil) {
+		return nil, entities.ErrAccountDeleted
+	}
+
+	return userDeletion, nil
+}
+
+// Отмена удаления аккаунта при входе
+
+func (s *authServiceImpl) restoreUser(ctx context.Context, userID int, clientInfo *entities.ClientInfo) error {
+	userDeletion, err := s.checkUserDeletion(ctx, userID)
+	if err != nil || userDeletion == nil {
+		return err
+	}
+
+	// Снимаем пометку на удаление. Если снять не удалось - очистка началась или удаление запросил администратор
+	// уже после проверки, иначе пометку успел снять параллельный вход
+	restored, err := s.userService.CancelUserDeletion(ctx, userID)
+	if err == nil && !restored {
+		_, err = s.checkUserDeletion(ctx, userID)
+	}
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionRestoreUser, entities.AuditTargetUser, strconv.Itoa(userID), err))
+
+	return err
+}
+
+// Запись попытки входа в журнал аудита. Пока пользователь не определен, объектом события является логин
+
+func (s *authServiceImpl) auditLogin(ctx context.Context, action string, userID int, login string, clientInfo *entities.ClientInfo, authResponse *entities.AuthResponse, err error) {
+	event := newAuditEvent(userID, clientInfo, action, "", "", err)
+	if userID != 0 {
+		event.TargetType = entities.AuditTargetUser
+		event.TargetID = strconv.Itoa(userID)
+	} else if login != "" {
+		event.TargetType = entities.AuditTargetLogin
+		event.TargetID = login
+	}
+	if authResponse != nil && authResponse.MFARequired {
+		event.Details = "two-factor code required"
+	}
+
+	s.auditLogger.Log(ctx, event)
+}
+
+// Проверка блокировки входа для логина и IP адреса
+
+func (s *authServiceImpl) checkLoginLock(ctx context.Context, login, ip string) error {
+	for scope, value := range map[string]string{"login": login, "ip": ip} {
+		lockTTL, err := s.tokenRepository.GetLoginLock(ctx, scope, value)
+		if err != nil {
+			return fmt.Errorf("login user error: %w", err)
+		}
+		if lockTTL > 0 {
+			return &entities.LoginLockedError{RetryAfter: lockTTL}
+		}
+	}
+
+	return nil
+}
+
+// Учет неудачной попытки входа, при превышении лимита вход блокируется с экспоненциально растущим временем
+
+func (s *authServiceImpl) registerFailedLogin(ctx context.Context, login string, clientInfo *entities.ClientInfo, loginErr error) error {
+	limits := map[string]struct {
+		value       string
+		maxAttempts int
+	}{
+		"login": {value: login, maxAttempts: s.loginProtection.MaxAttemptsPerLogin},
+		"ip":    {value: clientInfo.IP, maxAttempts: s.loginProtection.MaxAttemptsPerIP},
+	}
+
+	var lockErr *entities.LoginLockedError
+	for scope, limit := range limits {
+
+		// Увеличиваем счетчик неудачных попыток
+		attempts, err := s.tokenRepository.AddFailedLoginAttempt(ctx, scope, limit.value, s.loginProtection.AttemptsWindow)
+		if err != nil || attempts < limit.maxAttempts {
+			continue
+		}
+
+		// Блокируем вход
+		lockDuration := getLockoutDuration(s.loginProtection, attempts, limit.maxAttempts)
+		err = s.tokenRepository.LockLogin(ctx, scope, limit.value, lockDuration)
+		if err != nil {
+			continue
+		}
+
+		// Логируем событие блокировки
+		s.logger.Warn().
+			Str("scope", scope).
+			Str("login", login).
+			Str("ip", clientInfo.IP).
+			Str("userAgent", clientInfo.UserAgent).
+			Int("attempts", attempts).
+			Dur("lockDuration", lockDuration).
+			Msg("login locked out after failed attempts")
+
+		if lockErr == nil || lockDuration > lockErr.RetryAfter {
+			lockErr = &entities.LoginLockedError{RetryAfter: lockDuration}
+		}
+	}
+
+	if lockErr != nil {
+		return lockErr
+	}
+	return loginErr
+}
+
+// Время блокировки после превышения лимита: каждая следующая попытка сверх лимита удваивает время блокировки
+
+func getLockoutDuration(loginProtection *entities.LoginProtectionPolicy, attempts, maxAttempts int) time.Duration {
+	if exponent := attempts - maxAttempts; exponent < 32 {
+		return min(loginProtection.LockoutDuration<<exponent, loginProtection.MaxLockoutDuration)
+	}
+	return loginProtection.MaxLockoutDuration
+}
+
+// Создание новой сессии пользователя и выдача токенов для нее
+
+func (s *authServiceImpl) startSession(ctx context.Context, userID int, clientInfo *entities.ClientInfo) (*entities.TokenPair, error) {
+	now := time.Now()
+	session := &entities.Session{
+		ID:        utils.GenerateTokenID(),
+		UserAgent: clientInfo.UserAgent,
+		IP:        clientInfo.IP,
+		CreatedAt: now,
+	}
+
+	return s.issueSessionTokens(ctx, userID, session)
+}
+
+// Выдача токенов для сессии с новым семейством refresh токенов
+
+func (s *authServiceImpl) issueSessionTokens(ctx context.Context, userID int, session *entities.Session) (*entities.TokenPair, error) {
+
+	// Получаем роль пользователя, она передается в токенах
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Генерируем ID нового семейства refresh токенов
+	familyID := utils.GenerateTokenID()
+
+	// Генерируем пару access и refresh токенов
+	subject := &entities.TokenSubject{UserID: userID, SessionID: session.ID, FamilyID: familyID, Role: user.Role}
+	tokenPair, err := utils.CreateTokens(subject, s.keyring, s.accessTokenLifetime, s.refreshTokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Создаем семейство refresh токенов, без него токен не получится обновить
+	err = s.tokenRepository.CreateTokenFamily(ctx, familyID, tokenPair.RefreshTokenID, s.refreshTokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сохраняем токены в кеш, без ID access токена его нельзя будет отозвать
+	err = s.saveTokens(ctx, userID, tokenPair)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сохраняем данные сессии, без них сессию нельзя будет завершить, а ее refresh токен не обновится
+	session.LastRefreshAt = time.Now()
+	err = s.tokenRepository.SaveSession(ctx, userID, session, tokenPair.RefreshToken, familyID, s.refreshTokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenPair, nil
+}
+
+// Завершение сессии: удаление семейства refresh токенов и отзыв access токенов сессии
+
+func (s *authServiceImpl) revokeSession(ctx context.Context, userID int, sessionID string) error {
+
+	// Получаем сессию, ее текущий refresh токен и семейство
+	_, refreshToken, familyID, err := s.tokenRepository.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Удаляем семейство, ни один refresh токен сессии больше не обновится
+	err = s.tokenRepository.DeleteTokenFamily(ctx, familyID)
+	if err != nil {
+		return err
+	}
+
+	// Удаляем refresh токен сессии (токен мог уже истечь - не критично, без семейства он недействителен)
+	_ = s.tokenRepository.DeleteToken(ctx, userID, refreshToken, entities.RefreshTokenType)
+
+	// Отзываем все access токены сессии
+	err = s.tokenRepository.RevokeSession(ctx, sessionID, s.accessTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	// Удаляем сессию
+	return s.tokenRepository.DeleteSession(ctx, userID, sessionID)
+}
+
+// Отзыв семейства refresh токенов при повторном использовании токена
+
+func (s *authServiceImpl) revokeTokenFamily(ctx context.Context, tokenClaims *entities.TokenClaims, clientInfo *entities.ClientInfo) {
+
+	// Удаляем семейство, ни один токен из него больше не обновится
+	familyErr := s.tokenRepository.DeleteTokenFamily(ctx, tokenClaims.FamilyID)
+
+	// Завершаем сессию, к которой относится семейство
+	sessionErr := s.revokeSession(ctx, tokenClaims.UserID, tokenClaims.SessionID)
+
+	// Логируем событие безопасности
+	s.logger.Warn().
+		Int("userID", tokenClaims.UserID).
+		Str("sessionID", tokenClaims.SessionID).
+		Str("familyID", tokenClaims.FamilyID).
+		Str("ip", clientInfo.IP).
+		Str("userAgent", clientInfo.UserAgent).
+		AnErr("familyError", familyErr).
+		AnErr("sessionError", sessionErr).
+		Msg("refresh token reuse detected, token family revoked")
+}
+
+// Сохранение выданных токенов в кеш
+
+func (s *authServiceImpl) saveTokens(ctx context.Context, userID int, tokenPair *entities.TokenPair) error {
+	err := s.tokenRepository.AddToken(ctx, userID, tokenPair.AccessTokenID, entities.AccessTokenType, s.accessTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	return s.tokenRepository.AddToken(ctx, userID, tokenPair.RefreshToken, entities.RefreshTokenType, s.refreshTokenLifetime)
+}
+
+// Отзыв всех access и refresh токенов пользователя
+
+func (s *authServiceImpl) revokeAllTokens(ctx context.Context, userID int) error {
+
+	// Отзываем все выданные access токены
+	err := s.tokenRepository.RevokeAllAccessTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Удаляем все refresh токены
+	return s.tokenRepository.DeleteAllTokens(ctx, userID)
+}
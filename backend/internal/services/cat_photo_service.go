@@ -5,29 +5,31 @@ import (
 	"fmt"
 	"github.com/unwelcome/iqjtest/pkg/utils"
 	"mime/multipart"
+	"strconv"
 
 	"github.com/unwelcome/iqjtest/internal/entities"
 	"github.com/unwelcome/iqjtest/internal/repositories"
 )
 
 type CatPhotoService interface {
-	AddCatPhoto(ctx context.Context, catID int, photos []*multipart.FileHeader) *entities.CatPhotoUploadResponse
+	AddCatPhoto(ctx context.Context, userID, catID int, photos []*multipart.FileHeader, clientInfo *entities.ClientInfo) *entities.CatPhotoUploadResponse
 	GetCatPhotoByID(ctx context.Context, photoID int) (*entities.CatPhoto, error)
 	GetAllCatPhotos(ctx context.Context, catID int) ([]*entities.CatPhotoUrl, error)
-	SetCatPhotoPrimary(ctx context.Context, catID int, photoID int) (*entities.CatPhotoSetPrimaryResponse, error)
-	DeleteCatPhoto(ctx context.Context, catID, photoID int) error
+	SetCatPhotoPrimary(ctx context.Context, userID, catID, photoID int, clientInfo *entities.ClientInfo) (*entities.CatPhotoSetPrimaryResponse, error)
+	DeleteCatPhoto(ctx context.Context, userID, catID, photoID int, clientInfo *entities.ClientInfo) error
 	DeleteAllCatPhotos(ctx context.Context, catID int) error
 }
 
 type catPhotoServiceImpl struct {
 	catPhotoRepository repositories.CatPhotoRepository
+	auditLogger        AuditLogger
 }
 
-func NewCatPhotoService(catPhotoRepository repositories.CatPhotoRepository) CatPhotoService {
-	return &catPhotoServiceImpl{catPhotoRepository: catPhotoRepository}
+func NewCatPhotoService(catPhotoRepository repositories.CatPhotoRepository, auditLogger AuditLogger) CatPhotoService {
+	return &catPhotoServiceImpl{catPhotoRepository: catPhotoRepository, auditLogger: auditLogger}
 }
 
-func (s *catPhotoServiceImpl) AddCatPhoto(ctx context.Context, catID int, photos []*multipart.FileHeader) *entities.CatPhotoUploadResponse {
+func (s *catPhotoServiceImpl) AddCatPhoto(ctx context.Context, userID, catID int, photos []*multipart.FileHeader, clientInfo *entities.ClientInfo) *entities.CatPhotoUploadResponse {
 
 	// Создаем массив загруженных фото и массив с ошибками загрузки
 	var uploadedPhotos []*entities.CatPhotoUploadSuccess
@@ -76,11 +78,14 @@ func (s *catPhotoServiceImpl) AddCatPhoto(ctx context.Context, catID int, photos
 		if err != nil {
 			errors = append(errors, &entities.CatPhotoUploadError{
 				FileName: file.Filename,
-				Error:    fmt.Sprintf("add photo error: %w", err),
+				Error:    fmt.Sprintf("add photo error: %s", err.Error()),
 			})
 			continue
 		}
 		uploadedPhotos = append(uploadedPhotos, success)
+
+		// Записываем загрузку в журнал аудита
+		s.auditLogger.Log(ctx, newCatPhotoAuditEvent(userID, catID, success.ID, clientInfo, entities.AuditActionAddCatPhoto, nil))
 	}
 
 	// Создаем отчет о загрузке фото
@@ -117,18 +122,33 @@ func (s *catPhotoServiceImpl) GetAllCatPhotos(ctx context.Context, catID int) ([
 	return catPhotosUrl, nil
 }
 
-func (s *catPhotoServiceImpl) SetCatPhotoPrimary(ctx context.Context, catID int, photoID int) (*entities.CatPhotoSetPrimaryResponse, error) {
+func (s *catPhotoServiceImpl) SetCatPhotoPrimary(ctx context.Context, userID, catID, photoID int, clientInfo *entities.ClientInfo) (*entities.CatPhotoSetPrimaryResponse, error) {
 
 	// Устанавливаем главное фото кота
 	err := s.catPhotoRepository.SetCatPhotoPrimary(ctx, catID, photoID)
 	if err != nil {
-		return nil, fmt.Errorf("set cat photo primary error: %w", err)
+		err = fmt.Errorf("set cat photo primary error: %w", err)
+	}
+
+	// Записываем результат в журнал аудита
+	s.auditLogger.Log(ctx, newCatPhotoAuditEvent(userID, catID, photoID, clientInfo, entities.AuditActionSetPrimaryPhoto, err))
+	if err != nil {
+		return nil, err
 	}
 
 	return &entities.CatPhotoSetPrimaryResponse{ID: photoID}, nil
 }
 
-func (s *catPhotoServiceImpl) DeleteCatPhoto(ctx context.Context, catID, photoID int) error {
+func (s *catPhotoServiceImpl) DeleteCatPhoto(ctx context.Context, userID, catID, photoID int, clientInfo *entities.ClientInfo) error {
+
+	// Удаляем фото и записываем результат в журнал аудита
+	err := s.deleteCatPhoto(ctx, catID, photoID)
+	s.auditLogger.Log(ctx, newCatPhotoAuditEvent(userID, catID, photoID, clientInfo, entities.AuditActionDeleteCatPhoto, err))
+
+	return err
+}
+
+func (s *catPhotoServiceImpl) deleteCatPhoto(ctx context.Context, catID, photoID int) error {
 
 	// Получаем информацию о фото
 	catPhoto, err := s.catPhotoRepository.GetCatPhotoByID(ctx, photoID)
@@ -160,3 +180,14 @@ func (s *catPhotoServiceImpl) DeleteAllCatPhotos(ctx context.Context, catID int)
 
 	return nil
 }
+
+// Событие аудита для фото, ID кота сохраняется в details
+
+func newCatPhotoAuditEvent(userID, catID, photoID int, clientInfo *entities.ClientInfo, action string, err error) *entities.AuditEvent {
+	event := newAuditEvent(userID, clientInfo, action, entities.AuditTargetCatPhoto, strconv.Itoa(photoID), err)
+	if err == nil {
+		event.Details = fmt.Sprintf("cat_id: %d", catID)
+	}
+
+	return event
+}
@@ -3,32 +3,38 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/unwelcome/iqjtest/internal/entities"
 	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/utils"
 )
 
 type CatService interface {
-	CreateCat(ctx context.Context, userID int, catCreateRequest *entities.CatCreateRequestWithPhotos) (*entities.CatCreateResponse, error)
+	CreateCat(ctx context.Context, userID int, catCreateRequest *entities.CatCreateRequestWithPhotos, clientInfo *entities.ClientInfo) (*entities.CatCreateResponse, error)
 	GetCatByID(ctx context.Context, catID int) (*entities.CatWithPhotos, error)
-	GetAllCats(ctx context.Context) ([]*entities.CatWithPrimePhoto, error)
+	GetAllCats(ctx context.Context, filter *entities.CatListFilter) (*entities.CatListResponse, error)
+	GetUserCats(ctx context.Context, userID int) ([]*entities.CatWithPrimePhoto, error)
 	CheckOwnershipRight(ctx context.Context, userID, catID int) (bool, error)
 	UpdateCatName(ctx context.Context, catID int, catUpdateNameRequest *entities.CatUpdateNameRequest) (*entities.CatUpdateNameResponse, error)
 	UpdateCatAge(ctx context.Context, catID int, catUpdateAgeRequest *entities.CatUpdateAgeRequest) (*entities.CatUpdateAgeResponse, error)
 	UpdateCatDescription(ctx context.Context, catID int, catUpdateDescriptionRequest *entities.CatUpdateDescriptionRequest) (*entities.CatUpdateDescriptionResponse, error)
 	UpdateCat(ctx context.Context, catID int, catUpdateRequest *entities.CatUpdateRequest) (*entities.CatUpdateResponse, error)
-	DeleteCat(ctx context.Context, catID int) error
+	DeleteCat(ctx context.Context, userID, catID int, clientInfo *entities.ClientInfo) error
 }
 
 type catServiceImpl struct {
 	catRepository   repositories.CatRepository
 	catPhotoService CatPhotoService
+	auditLogger     AuditLogger
 }
 
-func NewCatService(catRepository repositories.CatRepository, catPhotoService CatPhotoService) CatService {
-	return &catServiceImpl{catRepository: catRepository, catPhotoService: catPhotoService}
+func NewCatService(catRepository repositories.CatRepository, catPhotoService CatPhotoService, auditLogger AuditLogger) CatService {
+	return &catServiceImpl{catRepository: catRepository, catPhotoService: catPhotoService, auditLogger: auditLogger}
 }
 
-func (s *catServiceImpl) CreateCat(ctx context.Context, userID int, catCreateRequest *entities.CatCreateRequestWithPhotos) (*entities.CatCreateResponse, error) {
+func (s *catServiceImpl) CreateCat(ctx context.Context, userID int, catCreateRequest *entities.CatCreateRequestWithPhotos, clientInfo *entities.ClientInfo) (*entities.CatCreateResponse, error) {
 	// Создаем кота
 	cat := &entities.Cat{
 		Name:        catCreateRequest.Fields.Name,
@@ -39,11 +45,14 @@ func (s *catServiceImpl) CreateCat(ctx context.Context, userID int, catCreateReq
 	// Добавляем кота в бд и получаем его ID
 	err := s.catRepository.CreateCat(ctx, userID, cat)
 	if err != nil {
-		return nil, fmt.Errorf("create cat error: %s", err.Error())
+		err = fmt.Errorf("create cat error: %s", err.Error())
+		s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionCreateCat, entities.AuditTargetCat, "", err))
+		return nil, err
 	}
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionCreateCat, entities.AuditTargetCat, strconv.Itoa(cat.ID), nil))
 
 	// Добавляем фото кота в S3
-	catPhotoUploadResponse := s.catPhotoService.AddCatPhoto(ctx, cat.ID, catCreateRequest.Photos)
+	catPhotoUploadResponse := s.catPhotoService.AddCatPhoto(ctx, userID, cat.ID, catCreateRequest.Photos, clientInfo)
 
 	return &entities.CatCreateResponse{ID: cat.ID, Photo: catPhotoUploadResponse}, nil
 }
@@ -75,14 +84,44 @@ func (s *catServiceImpl) GetCatByID(ctx context.Context, catID int) (*entities.C
 	return catWithPhotos, nil
 }
 
-func (s *catServiceImpl) GetAllCats(ctx context.Context) ([]*entities.CatWithPrimePhoto, error) {
+func (s *catServiceImpl) GetAllCats(ctx context.Context, filter *entities.CatListFilter) (*entities.CatListResponse, error) {
 
-	// Получаем всех котов
-	cats, err := s.catRepository.GetAllCats(ctx)
+	// Проверяем фильтры
+	err := validateCatListFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("get all cats error: %w", err)
+	}
+
+	// Получаем котов
+	cats, err := s.catRepository.GetAllCats(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("get all cats error: %s", err.Error())
 	}
 
+	// Считаем котов по фильтрам
+	total, err := s.catRepository.CountCats(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get all cats error: %s", err.Error())
+	}
+
+	// Следующая страница начинается после последнего кота текущей
+	response := &entities.CatListResponse{Cats: cats, Total: total}
+	if len(cats) > filter.Limit {
+		response.Cats = cats[:filter.Limit]
+		response.NextCursor = catListCursor(filter.Sort, response.Cats[filter.Limit-1])
+	}
+
+	return response, nil
+}
+
+func (s *catServiceImpl) GetUserCats(ctx context.Context, userID int) ([]*entities.CatWithPrimePhoto, error) {
+
+	// Получаем котов, созданных пользователем
+	cats, err := s.catRepository.GetCatsByOwner(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user cats error: %w", err)
+	}
+
 	return cats, nil
 }
 
@@ -147,7 +186,16 @@ func (s *catServiceImpl) UpdateCat(ctx context.Context, catID int, catUpdateRequ
 	}, nil
 }
 
-func (s *catServiceImpl) DeleteCat(ctx context.Context, catID int) error {
+func (s *catServiceImpl) DeleteCat(ctx context.Context, userID, catID int, clientInfo *entities.ClientInfo) error {
+
+	// Удаляем кота и записываем результат в журнал аудита
+	err := s.deleteCat(ctx, catID)
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionDeleteCat, entities.AuditTargetCat, strconv.Itoa(catID), err))
+
+	return err
+}
+
+func (s *catServiceImpl) deleteCat(ctx context.Context, catID int) error {
 
 	// Удаляем все фото кота
 	err := s.catPhotoService.DeleteAllCatPhotos(ctx, catID)
@@ -163,3 +211,107 @@ func (s *catServiceImpl) DeleteCat(ctx context.Context, catID int) error {
 
 	return nil
 }
+
+// Проверка фильтров списка котов и значения по умолчанию:
+// сортировка по дате создания (новые первыми), по кличке и возрасту - по возрастанию
+
+func validateCatListFilter(filter *entities.CatListFilter) error {
+
+	// Проверяем количество котов
+	if filter.Limit == 0 {
+		filter.Limit = entities.CatsDefaultLimit
+	}
+	if filter.Limit < 0 || filter.Limit > entities.CatsMaxLimit {
+		return entities.NewValidationError("limit must be between 1 and %d", entities.CatsMaxLimit)
+	}
+
+	// Проверяем диапазон возраста
+	if filter.AgeMin != nil && *filter.AgeMin < 0 {
+		return entities.NewValidationError("invalid age_min")
+	}
+	if filter.AgeMax != nil && *filter.AgeMax < 0 {
+		return entities.NewValidationError("invalid age_max")
+	}
+	if filter.AgeMin != nil && filter.AgeMax != nil && *filter.AgeMin > *filter.AgeMax {
+		return entities.NewValidationError("age_min must not be greater than age_max")
+	}
+
+	// Проверяем владельца
+	if filter.OwnerID < 0 {
+		return entities.NewValidationError("invalid owner_id")
+	}
+
+	// Парсим границы периода создания (RFC 3339). Дата создания хранится в UTC без часового пояса,
+	// поэтому границы тоже приводим к UTC
+	if filter.CreatedFrom != "" {
+		createdFrom, err := time.Parse(time.RFC3339, filter.CreatedFrom)
+		if err != nil {
+			return entities.NewValidationError("invalid created_from")
+		}
+		filter.CreatedFromTime = createdFrom.UTC()
+	}
+	if filter.CreatedTo != "" {
+		createdTo, err := time.Parse(time.RFC3339, filter.CreatedTo)
+		if err != nil {
+			return entities.NewValidationError("invalid created_to")
+		}
+		filter.CreatedToTime = createdTo.UTC()
+	}
+
+	// Проверяем сортировку
+	if filter.Sort == "" {
+		filter.Sort = entities.CatSortCreatedAt
+	}
+	if filter.Sort != entities.CatSortName && filter.Sort != entities.CatSortAge && filter.Sort != entities.CatSortCreatedAt {
+		return entities.NewValidationError("invalid sort")
+	}
+	if filter.Order == "" {
+		filter.Order = entities.SortOrderAsc
+		if filter.Sort == entities.CatSortCreatedAt {
+			filter.Order = entities.SortOrderDesc
+		}
+	}
+	if filter.Order != entities.SortOrderAsc && filter.Order != entities.SortOrderDesc {
+		return entities.NewValidationError("invalid order")
+	}
+
+	// Парсим курсор: поле сортировки, его значение и ID последнего кота предыдущей страницы.
+	// Курсор, выданный для другой сортировки, не принимается
+	if filter.Cursor != "" {
+		values, err := utils.DecodeCursor(filter.Cursor, 3)
+		if err != nil || values[0] != filter.Sort {
+			return entities.NewValidationError("invalid cursor")
+		}
+
+		switch filter.Sort {
+		case entities.CatSortName:
+			filter.CursorValue = values[1]
+		case entities.CatSortAge:
+			filter.CursorValue, err = strconv.Atoi(values[1])
+		case entities.CatSortCreatedAt:
+			filter.CursorValue, err = time.Parse(time.RFC3339Nano, values[1])
+		}
+		if err == nil {
+			filter.CursorID, err = strconv.Atoi(values[2])
+		}
+		if err != nil {
+			return entities.NewValidationError("invalid cursor")
+		}
+	}
+
+	return nil
+}
+
+// Курсор следующей страницы по последнему коту текущей
+
+func catListCursor(sort string, cat *entities.CatWithPrimePhoto) string {
+	value := cat.CreatedAt
+	switch sort {
+	case entities.CatSortName:
+		value = cat.Name
+	case entities.CatSortAge:
+		value = strconv.Itoa(cat.Age)
+	}
+
+	return utils.EncodeCursor(sort, value, strconv.Itoa(cat.ID))
+}
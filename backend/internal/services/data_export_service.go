@@ -0,0 +1,330 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type DataExportService interface {
+	StartDataExport(ctx context.Context, userID int, clientInfo *entities.ClientInfo) (*entities.DataExport, error)
+	GetDataExport(ctx context.Context, userID int) (*entities.DataExport, error)
+	GetDataExportDownload(ctx context.Context, userID int) (*entities.DataExportDownloadResponse, error)
+}
+
+type dataExportServiceImpl struct {
+	userRepository       repositories.UserRepository
+	catRepository        repositories.CatRepository
+	catPhotoRepository   repositories.CatPhotoRepository
+	dataExportRepository repositories.DataExportRepository
+	auditLogger          AuditLogger
+	logger               zerolog.Logger
+
+	lifetime     time.Duration
+	linkLifetime time.Duration
+	buildTimeout time.Duration
+}
+
+func NewDataExportService(
+	userRepository repositories.UserRepository,
+	catRepository repositories.CatRepository,
+	catPhotoRepository repositories.CatPhotoRepository,
+	dataExportRepository repositories.DataExportRepository,
+	auditLogger AuditLogger,
+	logger zerolog.Logger,
+	lifetime time.Duration,
+	linkLifetime time.Duration,
+	buildTimeout time.Duration,
+) DataExportService {
+	return &dataExportServiceImpl{
+		userRepository:       userRepository,
+		catRepository:        catRepository,
+		catPhotoRepository:   catPhotoRepository,
+		dataExportRepository: dataExportRepository,
+		auditLogger:          auditLogger,
+		logger:               logger,
+
+		lifetime:     lifetime,
+		linkLifetime: linkLifetime,
+		buildTimeout: buildTimeout,
+	}
+}
+
+func (s *dataExportServiceImpl) StartDataExport(ctx context.Context, userID int, clientInfo *entities.ClientInfo) (*entities.DataExport, error) {
+
+	// Запускаем выгрузку и записываем результат в журнал аудита
+	dataExport, err := s.startDataExport(ctx, userID)
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionDataExport, entities.AuditTargetUser, strconv.Itoa(userID), err))
+
+	return dataExport, err
+}
+
+func (s *dataExportServiceImpl) startDataExport(ctx context.Context, userID int) (*entities.DataExport, error) {
+
+	dataExport := &entities.DataExport{
+		ID:        utils.GenerateTokenID(),
+		UserID:    userID,
+		Status:    entities.DataExportStatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	// Одновременно у пользователя может собираться только одна выгрузка. Сборка занимается атомарно,
+	// блокировка истекает через время сборки (например, если сервер перезапустился во время сборки)
+	claimed, err := s.dataExportRepository.ClaimDataExportBuild(ctx, userID, dataExport.ID, s.buildTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("start data export error: %w", err)
+	}
+	if !claimed {
+		return nil, entities.ErrDataExportInProgress
+	}
+
+	// Новая выгрузка заменяет предыдущую, ее архив больше не нужен
+	previous, err := s.dataExportRepository.GetDataExport(ctx, userID)
+	if err != nil && !errors.Is(err, entities.ErrDataExportNotFound) {
+		_ = s.dataExportRepository.ReleaseDataExportBuild(ctx, userID, dataExport.ID)
+		return nil, fmt.Errorf("start data export error: %w", err)
+	}
+	if previous != nil && previous.Status == entities.DataExportStatusReady {
+		_ = s.dataExportRepository.DeleteArchive(ctx, dataExportObjectName(previous))
+	}
+
+	// Сохраняем выгрузку в очереди
+	err = s.dataExportRepository.SaveDataExport(ctx, dataExport, s.lifetime)
+	if err != nil {
+		_ = s.dataExportRepository.ReleaseDataExportBuild(ctx, userID, dataExport.ID)
+		return nil, fmt.Errorf("start data export error: %w", err)
+	}
+
+	// Собираем архив в фоне, запрос не ждет окончания сборки
+	go s.buildDataExport(*dataExport)
+
+	return dataExport, nil
+}
+
+func (s *dataExportServiceImpl) GetDataExport(ctx context.Context, userID int) (*entities.DataExport, error) {
+
+	// Получаем последнюю выгрузку пользователя
+	dataExport, err := s.dataExportRepository.GetDataExport(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get data export error: %w", err)
+	}
+
+	return dataExport, nil
+}
+
+func (s *dataExportServiceImpl) GetDataExportDownload(ctx context.Context, userID int) (*entities.DataExportDownloadResponse, error) {
+
+	// Получаем последнюю выгрузку пользователя
+	dataExport, err := s.dataExportRepository.GetDataExport(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get data export download error: %w", err)
+	}
+
+	// Скачать можно только собранный архив
+	if dataExport.Status != entities.DataExportStatusReady {
+		return nil, entities.ErrDataExportNotReady
+	}
+
+	// Подписываем временную ссылку на архив
+	url, err := s.dataExportRepository.GetArchiveUrl(ctx, dataExportObjectName(dataExport), s.linkLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("get data export download error: %w", err)
+	}
+
+	return &entities.DataExportDownloadResponse{Url: url, ExpiresAt: time.Now().Add(s.linkLifetime).UTC()}, nil
+}
+
+// Сборка архива выгрузки: profile.json, cats.json, оригиналы фото котов в photos/{cat_id}/ и manifest.json
+
+func (s *dataExportServiceImpl) buildDataExport(dataExport entities.DataExport) {
+
+	// Ограничение времени сборки, не зависит от запроса, запустившего выгрузку
+	ctx, cancel := context.WithTimeout(context.Background(), s.buildTimeout)
+	defer cancel()
+
+	// Отмечаем начало сборки
+	dataExport.Status = entities.DataExportStatusProcessing
+	err := s.dataExportRepository.SaveDataExport(ctx, &dataExport, s.lifetime)
+	if err == nil {
+		dataExport.Size, err = s.writeDataExport(ctx, &dataExport)
+	}
+
+	// Сохраняем результат сборки
+	completedAt := time.Now().UTC()
+	dataExport.CompletedAt = &completedAt
+	if err != nil {
+		s.logger.Error().Err(err).Int("userID", dataExport.UserID).Str("dataExportID", dataExport.ID).Msg("failed to build data export")
+		dataExport.Status = entities.DataExportStatusFailed
+		dataExport.Error = "failed to build data export"
+	} else {
+		expiresAt := completedAt.Add(s.lifetime)
+		dataExport.Status = entities.DataExportStatusReady
+		dataExport.ExpiresAt = &expiresAt
+	}
+
+	// Сохраняем с новым контекстом: при истечении времени сборки статус failed все равно должен сохраниться
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer saveCancel()
+	err = s.dataExportRepository.SaveDataExport(saveCtx, &dataExport, s.lifetime)
+	if err != nil {
+		s.logger.Error().Err(err).Int("userID", dataExport.UserID).Str("dataExportID", dataExport.ID).Msg("failed to save data export")
+	}
+
+	// Освобождаем сборку, теперь можно запустить новую выгрузку (если не получилось - блокировка истечет сама)
+	err = s.dataExportRepository.ReleaseDataExportBuild(saveCtx, dataExport.UserID, dataExport.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Int("userID", dataExport.UserID).Str("dataExportID", dataExport.ID).Msg("failed to release data export build")
+	}
+}
+
+// Архив собирается во временном файле: для загрузки в minio нужен известный размер, а держать архив с фото в памяти нельзя
+
+func (s *dataExportServiceImpl) writeDataExport(ctx context.Context, dataExport *entities.DataExport) (int64, error) {
+
+	// Создаем временный файл архива
+	file, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file error: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// Записываем данные пользователя в архив
+	archive := zip.NewWriter(file)
+	err = s.writeDataExportArchive(ctx, archive, dataExport.UserID)
+	if err != nil {
+		return 0, err
+	}
+	err = archive.Close()
+	if err != nil {
+		return 0, fmt.Errorf("close archive error: %w", err)
+	}
+
+	// Загружаем архив в закрытый бакет
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("archive size error: %w", err)
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("archive seek error: %w", err)
+	}
+	err = s.dataExportRepository.UploadArchive(ctx, dataExportObjectName(dataExport), file, size)
+	if err != nil {
+		return 0, fmt.Errorf("upload archive error: %w", err)
+	}
+
+	return size, nil
+}
+
+func (s *dataExportServiceImpl) writeDataExportArchive(ctx context.Context, archive *zip.Writer, userID int) error {
+
+	// Профиль пользователя
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user error: %w", err)
+	}
+	userEmail, err := s.userRepository.GetUserEmail(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user email error: %w", err)
+	}
+	profile := &entities.DataExportProfile{UserGet: user, Role: user.Role, Email: userEmail.Email, EmailVerified: userEmail.Verified}
+	err = writeArchiveJSON(archive, "profile.json", profile)
+	if err != nil {
+		return err
+	}
+
+	// Коты пользователя с метаданными фото
+	cats, err := s.catRepository.GetAllUserCats(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user cats error: %w", err)
+	}
+	exportCats := make([]*entities.DataExportCat, 0, len(cats))
+	for _, cat := range cats {
+		photos, err := s.catPhotoRepository.GetAllCatPhotosInfo(ctx, cat.ID)
+		if err != nil {
+			return fmt.Errorf("get cat photos error: %w", err)
+		}
+		exportCats = append(exportCats, &entities.DataExportCat{Cat: cat, Photos: photos})
+	}
+	err = writeArchiveJSON(archive, "cats.json", exportCats)
+	if err != nil {
+		return err
+	}
+
+	// Оригиналы фото из minio. Фото без файла в хранилище не должно ломать всю выгрузку, такие фото записываем в опись
+	manifest := &entities.DataExportManifest{MissingPhotos: []*entities.CatPhoto{}}
+	for _, cat := range exportCats {
+		for _, photo := range cat.Photos {
+			err = s.writeArchivePhoto(ctx, archive, photo)
+			if errors.Is(err, entities.ErrPhotoFileNotFound) {
+				s.logger.Warn().Int("userID", userID).Int("photoID", photo.ID).Str("fileName", photo.FileName).Msg("photo file not found, skipped in data export")
+				manifest.MissingPhotos = append(manifest.MissingPhotos, photo)
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeArchiveJSON(archive, "manifest.json", manifest)
+}
+
+func (s *dataExportServiceImpl) writeArchivePhoto(ctx context.Context, archive *zip.Writer, photo *entities.CatPhoto) error {
+
+	// Получаем оригинал фото
+	object, err := s.catPhotoRepository.GetCatPhotoObject(ctx, photo.FileName)
+	if err != nil {
+		return fmt.Errorf("get photo %d error: %w", photo.ID, err)
+	}
+	defer object.Close()
+
+	// Копируем фото в архив без сжатия, изображения уже сжаты
+	writer, err := archive.CreateHeader(&zip.FileHeader{
+		Name:   fmt.Sprintf("photos/%d/%d_%s", photo.CatID, photo.ID, path.Base(photo.FileName)),
+		Method: zip.Store,
+	})
+	if err != nil {
+		return fmt.Errorf("write photo %d error: %w", photo.ID, err)
+	}
+	_, err = io.Copy(writer, object)
+	if err != nil {
+		return fmt.Errorf("write photo %d error: %w", photo.ID, err)
+	}
+
+	return nil
+}
+
+func writeArchiveJSON(archive *zip.Writer, name string, data any) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("write %s error: %w", name, err)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(data)
+	if err != nil {
+		return fmt.Errorf("write %s error: %w", name, err)
+	}
+
+	return nil
+}
+
+// Имя архива в бакете: user/{user_id}/{export_id}.zip
+
+func dataExportObjectName(dataExport *entities.DataExport) string {
+	return fmt.Sprintf("user/%d/%s.zip", dataExport.UserID, dataExport.ID)
+}
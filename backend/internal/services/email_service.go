@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/mailer"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type EmailService interface {
+	GetUserEmail(ctx context.Context, userID int) (*entities.UserEmail, error)
+	UpdateUserEmail(ctx context.Context, userID int, userUpdateEmailRequest *entities.UserUpdateEmailRequest) error
+	SendVerificationEmail(ctx context.Context, userID int) error
+	ConfirmEmailVerification(ctx context.Context, emailVerificationConfirmRequest *entities.EmailVerificationConfirmRequest) error
+	CheckEmailVerified(ctx context.Context, userID int) (bool, error)
+}
+
+type emailServiceImpl struct {
+	userRepository  repositories.UserRepository
+	tokenRepository repositories.AuthRepository
+	mailer          mailer.Mailer
+	logger          zerolog.Logger
+
+	verificationTokenLifetime time.Duration
+	verificationURL           string
+}
+
+func NewEmailService(
+	userRepository repositories.UserRepository,
+	tokenRepository repositories.AuthRepository,
+	mailer mailer.Mailer,
+	logger zerolog.Logger,
+	verificationTokenLifetime time.Duration,
+	verificationURL string,
+) EmailService {
+	return &emailServiceImpl{
+		userRepository:  userRepository,
+		tokenRepository: tokenRepository,
+		mailer:          mailer,
+		logger:          logger,
+
+		verificationTokenLifetime: verificationTokenLifetime,
+		verificationURL:           verificationURL,
+	}
+}
+
+func (s *emailServiceImpl) GetUserEmail(ctx context.Context, userID int) (*entities.UserEmail, error) {
+
+	// Получаем email пользователя
+	userEmail, err := s.userRepository.GetUserEmail(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user email error: %w", err)
+	}
+
+	return userEmail, nil
+}
+
+func (s *emailServiceImpl) UpdateUserEmail(ctx context.Context, userID int, userUpdateEmailRequest *entities.UserUpdateEmailRequest) error {
+
+	// Проверяем email (пустая строка - удаление email)
+	userUpdateEmailRequest.Email = normalizeEmail(userUpdateEmailRequest.Email)
+	if userUpdateEmailRequest.Email != "" && !validateEmail(userUpdateEmailRequest.Email) {
+		return fmt.Errorf("update user email error: %w", entities.ErrInvalidEmail)
+	}
+
+	// Обновляем email, он становится неподтвержденным
+	err := s.userRepository.UpdateUserEmail(ctx, userID, userUpdateEmailRequest.Email)
+	if err != nil {
+		return fmt.Errorf("update user email error: %w", err)
+	}
+
+	// Удаляем токен подтверждения предыдущего email
+	err = s.tokenRepository.DeleteUserOneTimeToken(ctx, entities.EmailVerificationTokenPurpose, userID)
+	if err != nil {
+		return fmt.Errorf("update user email error: %w", err)
+	}
+
+	// Отправляем письмо для подтверждения нового email
+	if userUpdateEmailRequest.Email != "" {
+		return s.SendVerificationEmail(ctx, userID)
+	}
+
+	return nil
+}
+
+func (s *emailServiceImpl) SendVerificationEmail(ctx context.Context, userID int) error {
+
+	// Получаем email пользователя
+	userEmail, err := s.userRepository.GetUserEmail(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("send verification email error: %w", err)
+	}
+	if userEmail.Email == "" {
+		return fmt.Errorf("send verification email error: email not set")
+	}
+	if userEmail.Verified {
+		return fmt.Errorf("send verification email error: email already verified")
+	}
+
+	// Генерируем токен подтверждения для текущего email, предыдущий токен перестает действовать
+	verificationToken := utils.GenerateTokenID()
+	err = s.tokenRepository.SaveOneTimeToken(ctx, entities.EmailVerificationTokenPurpose, utils.HashToken(verificationToken), &entities.OneTimeToken{UserID: userID, Email: userEmail.Email}, s.verificationTokenLifetime)
+	if err != nil {
+		return fmt.Errorf("send verification email error: %w", err)
+	}
+
+	// Формируем ссылку для подтверждения
+	verificationURL, err := url.Parse(s.verificationURL)
+	if err != nil {
+		return fmt.Errorf("send verification email error: %w", err)
+	}
+	query := verificationURL.Query()
+	query.Set("token", verificationToken)
+	verificationURL.RawQuery = query.Encode()
+
+	// Отправляем письмо
+	err = s.mailer.Send(ctx, &entities.Mail{
+		To:      userEmail.Email,
+		Subject: "Подтверждение email",
+		Body:    fmt.Sprintf("Для подтверждения email перейдите по ссылке: %s\nСсылка действительна %d часов.", verificationURL.String(), int(s.verificationTokenLifetime.Hours())),
+	})
+	if err != nil {
+		return fmt.Errorf("send verification email error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *emailServiceImpl) ConfirmEmailVerification(ctx context.Context, emailVerificationConfirmRequest *entities.EmailVerificationConfirmRequest) error {
+
+	// Используем токен подтверждения, повторно он не сработает
+	verificationToken, err := s.tokenRepository.ConsumeOneTimeToken(ctx, entities.EmailVerificationTokenPurpose, utils.HashToken(emailVerificationConfirmRequest.Token))
+	if err != nil {
+		return fmt.Errorf("confirm email error: %w", err)
+	}
+
+	// Отмечаем email подтвержденным, только если он совпадает с email, на который было отправлено письмо
+	err = s.userRepository.SetUserEmailVerified(ctx, verificationToken.UserID, verificationToken.Email)
+	if err != nil {
+		return fmt.Errorf("confirm email error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *emailServiceImpl) CheckEmailVerified(ctx context.Context, userID int) (bool, error) {
+
+	// Получаем email пользователя
+	userEmail, err := s.userRepository.GetUserEmail(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("check email verified error: %w", err)
+	}
+
+	return userEmail.Verified, nil
+}
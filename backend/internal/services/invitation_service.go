@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type InvitationService interface {
+	CreateInvitation(ctx context.Context, userID int, userRole string, invitationCreateRequest *entities.InvitationCreateRequest) (*entities.InvitationCreateResponse, error)
+	GetAllInvitations(ctx context.Context, userID int) ([]*entities.Invitation, error)
+	DeleteInvitation(ctx context.Context, userID, invitationID int) error
+}
+
+type invitationServiceImpl struct {
+	invitationRepository repositories.InvitationRepository
+	creatorRole          string
+	invitationLifetime   time.Duration
+}
+
+func NewInvitationService(invitationRepository repositories.InvitationRepository, creatorRole string, invitationLifetime time.Duration) InvitationService {
+	return &invitationServiceImpl{invitationRepository: invitationRepository, creatorRole: creatorRole, invitationLifetime: invitationLifetime}
+}
+
+func (s *invitationServiceImpl) CreateInvitation(ctx context.Context, userID int, userRole string, invitationCreateRequest *entities.InvitationCreateRequest) (*entities.InvitationCreateResponse, error) {
+
+	// Проверяем, что пользователь может создавать приглашения
+	if !entities.HasRole(userRole, s.creatorRole) {
+		return nil, fmt.Errorf("create invitation error: insufficient role")
+	}
+
+	// Роль новому пользователю может назначить только администратор
+	if invitationCreateRequest.Role != "" {
+		if !entities.HasRole(userRole, entities.RoleAdmin) {
+			return nil, fmt.Errorf("create invitation error: only admin can assign role")
+		}
+		if !entities.IsValidRole(invitationCreateRequest.Role) {
+			return nil, fmt.Errorf("create invitation error: invalid role")
+		}
+	}
+
+	// Проверяем количество использований (по умолчанию приглашение одноразовое)
+	if invitationCreateRequest.MaxUses == 0 {
+		invitationCreateRequest.MaxUses = 1
+	}
+	if invitationCreateRequest.MaxUses < 0 || invitationCreateRequest.MaxUses > entities.InvitationMaxUses {
+		return nil, fmt.Errorf("create invitation error: max_uses must be between 1 and %d", entities.InvitationMaxUses)
+	}
+
+	// Проверяем срок действия (по умолчанию - время жизни приглашения из конфига)
+	expiresAt := invitationCreateRequest.ExpiresAt
+	if expiresAt == nil {
+		defaultExpiresAt := time.Now().Add(s.invitationLifetime)
+		expiresAt = &defaultExpiresAt
+	} else if expiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("create invitation error: expiration date in the past")
+	}
+
+	// Генерируем код, в бд храним только его хеш и префикс для отображения
+	code := entities.InvitationCodePrefix + utils.GenerateTokenID()
+	invitation := &entities.Invitation{
+		CreatedBy: userID,
+		Prefix:    code[:len(entities.InvitationCodePrefix)+8],
+		Role:      invitationCreateRequest.Role,
+		MaxUses:   invitationCreateRequest.MaxUses,
+		ExpiresAt: expiresAt,
+	}
+
+	// Сохраняем приглашение
+	err := s.invitationRepository.CreateInvitation(ctx, invitation, utils.HashToken(code))
+	if err != nil {
+		return nil, fmt.Errorf("create invitation error: %w", err)
+	}
+
+	return &entities.InvitationCreateResponse{Invitation: invitation, Code: code}, nil
+}
+
+func (s *invitationServiceImpl) GetAllInvitations(ctx context.Context, userID int) ([]*entities.Invitation, error) {
+
+	// Получаем все приглашения пользователя
+	invitations, err := s.invitationRepository.GetAllInvitations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all invitations error: %w", err)
+	}
+
+	return invitations, nil
+}
+
+func (s *invitationServiceImpl) DeleteInvitation(ctx context.Context, userID, invitationID int) error {
+
+	// Удаляем приглашение
+	err := s.invitationRepository.DeleteInvitation(ctx, userID, invitationID)
+	if err != nil {
+		return fmt.Errorf("delete invitation error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/oidc"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type OIDCService interface {
+	StartLogin(ctx context.Context, linkUserID int) (*entities.OIDCAuthorizationResponse, string, error)
+	CompleteLogin(ctx context.Context, callback *entities.OIDCCallbackRequest, stateCookie string) (int, error)
+	GetAllIdentities(ctx context.Context, userID int) ([]*entities.UserIdentity, error)
+	DeleteIdentity(ctx context.Context, userID, identityID int) error
+}
+
+// Максимальная длина логина, созданного из данных провайдера
+const oidcLoginMaxLength = 32
+
+type oidcServiceImpl struct {
+	provider               oidc.Provider
+	userIdentityRepository repositories.UserIdentityRepository
+	userRepository         repositories.UserRepository
+	userService            UserService
+	authRepository         repositories.AuthRepository
+	registrationMode       string
+	stateLifetime          time.Duration
+}
+
+// provider == nil - вход через OIDC отключен
+
+func NewOIDCService(
+	provider oidc.Provider,
+	userIdentityRepository repositories.UserIdentityRepository,
+	userRepository repositories.UserRepository,
+	userService UserService,
+	authRepository repositories.AuthRepository,
+	registrationMode string,
+	stateLifetime time.Duration,
+) OIDCService {
+	return &oidcServiceImpl{
+		provider:               provider,
+		userIdentityRepository: userIdentityRepository,
+		userRepository:         userRepository,
+		userService:            userService,
+		authRepository:         authRepository,
+		registrationMode:       registrationMode,
+		stateLifetime:          stateLifetime,
+	}
+}
+
+func (s *oidcServiceImpl) StartLogin(ctx context.Context, linkUserID int) (*entities.OIDCAuthorizationResponse, string, error) {
+	if s.provider == nil {
+		return nil, "", entities.ErrOIDCDisabled
+	}
+
+	// Генерируем state (защита от CSRF), nonce (привязка ID токена к запросу) и code_verifier (PKCE)
+	state := utils.GenerateTokenID()
+	oidcState := &entities.OIDCState{
+		CodeVerifier: oidc.GenerateCodeVerifier(),
+		Nonce:        utils.GenerateTokenID(),
+		LinkUserID:   linkUserID,
+	}
+
+	// Формируем ссылку на страницу входа провайдера
+	authorizationURL, err := s.provider.AuthCodeURL(ctx, state, oidcState.Nonce, oidc.CodeChallengeS256(oidcState.CodeVerifier))
+	if err != nil {
+		return nil, "", fmt.Errorf("start oidc login error: %w", err)
+	}
+
+	// Сохраняем состояние до возврата пользователя, в кеше храним только хеш state
+	err = s.authRepository.SaveOIDCState(ctx, utils.HashToken(state), oidcState, s.stateLifetime)
+	if err != nil {
+		return nil, "", fmt.Errorf("start oidc login error: %w", err)
+	}
+
+	return &entities.OIDCAuthorizationResponse{AuthorizationURL: authorizationURL}, state, nil
+}
+
+func (s *oidcServiceImpl) CompleteLogin(ctx context.Context, callback *entities.OIDCCallbackRequest, stateCookie string) (int, error) {
+	if s.provider == nil {
+		return 0, entities.ErrOIDCDisabled
+	}
+
+	// Провайдер вернул ошибку (например, пользователь отказался от входа)
+	if callback.Error != "" {
+		return 0, fmt.Errorf("oidc login error: %s %s", callback.Error, callback.ErrorDescription)
+	}
+	if callback.Code == "" || callback.State == "" {
+		return 0, fmt.Errorf("oidc login error: code and state required")
+	}
+
+	// State должен совпадать с cookie браузера, начавшего вход, иначе чужой callback может войти в чужой аккаунт
+	if subtle.ConstantTimeCompare([]byte(callback.State), []byte(stateCookie)) != 1 {
+		return 0, fmt.Errorf("oidc login error: state mismatch")
+	}
+
+	// Используем состояние, повторно оно не сработает
+	oidcState, err := s.authRepository.ConsumeOIDCState(ctx, utils.HashToken(callback.State))
+	if err != nil {
+		return 0, fmt.Errorf("oidc login error: %w", err)
+	}
+
+	// Обмениваем код на токены и проверяем ID токен
+	tokenResponse, err := s.provider.Exchange(ctx, callback.Code, oidcState.CodeVerifier)
+	if err != nil {
+		return 0, fmt.Errorf("oidc login error: %w", err)
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, tokenResponse.IDToken, oidcState.Nonce)
+	if err != nil {
+		return 0, fmt.Errorf("oidc login error: %w", err)
+	}
+
+	// Привязка внешнего аккаунта к текущему пользователю
+	if oidcState.LinkUserID != 0 {
+		err = s.linkIdentity(ctx, oidcState.LinkUserID, claims)
+		if err != nil {
+			return 0, fmt.Errorf("oidc link error: %w", err)
+		}
+		return oidcState.LinkUserID, nil
+	}
+
+	// Аккаунт уже привязан - входим в него
+	identity, err := s.userIdentityRepository.GetUserIdentity(ctx, s.provider.Name(), claims.Subject)
+	if err == nil {
+		return identity.UserID, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("oidc login error: %w", err)
+	}
+
+	// Email подтвержден и провайдером, и у нас - привязываем аккаунт к существующему пользователю
+	if claims.Email != "" && claims.EmailVerified {
+		user, err := s.userRepository.GetUserByEmail(ctx, claims.Email)
+		if err == nil && user.EmailVerified {
+			err = s.linkIdentity(ctx, user.ID, claims)
+			if err != nil {
+				return 0, fmt.Errorf("oidc login error: %w", err)
+			}
+			return user.ID, nil
+		} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("oidc login error: %w", err)
+		}
+	}
+
+	// Иначе создаем нового пользователя (только при открытой регистрации: код приглашения через провайдера не передать)
+	if s.registrationMode != entities.RegistrationModeOpen {
+		return 0, entities.ErrRegistrationClosed
+	}
+	userID, err := s.createUser(ctx, claims)
+	if err != nil {
+		return 0, fmt.Errorf("oidc login error: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (s *oidcServiceImpl) GetAllIdentities(ctx context.Context, userID int) ([]*entities.UserIdentity, error) {
+
+	// Получаем все внешние аккаунты пользователя
+	identities, err := s.userIdentityRepository.GetAllUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all identities error: %w", err)
+	}
+
+	return identities, nil
+}
+
+func (s *oidcServiceImpl) DeleteIdentity(ctx context.Context, userID, identityID int) error {
+
+	// Удаляем привязку внешнего аккаунта
+	err := s.userIdentityRepository.DeleteUserIdentity(ctx, userID, identityID)
+	if err != nil {
+		return fmt.Errorf("delete identity error: %w", err)
+	}
+
+	return nil
+}
+
+// Привязка внешнего аккаунта к пользователю
+
+func (s *oidcServiceImpl) linkIdentity(ctx context.Context, userID int, claims *entities.OIDCIDTokenClaims) error {
+
+	// Аккаунт уже привязан - к этому пользователю повторно ничего не делаем, к другому - запрещаем
+	identity, err := s.userIdentityRepository.GetUserIdentity(ctx, s.provider.Name(), claims.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return fmt.Errorf("identity already linked to another user")
+		}
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Сохраняем привязку
+	return s.userIdentityRepository.CreateUserIdentity(ctx, &entities.UserIdentity{
+		UserID:   userID,
+		Provider: s.provider.Name(),
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+}
+
+// Создание пользователя по данным провайдера
+
+func (s *oidcServiceImpl) createUser(ctx context.Context, claims *entities.OIDCIDTokenClaims) (int, error) {
+
+	// Подбираем свободный логин
+	login, err := s.generateLogin(ctx, claims)
+	if err != nil {
+		return 0, err
+	}
+
+	// Email провайдера сохраняем, только если он подтвержден и не занят другим пользователем
+	email := ""
+	if claims.Email != "" && claims.EmailVerified {
+		_, err = s.userRepository.GetUserByEmail(ctx, claims.Email)
+		if errors.Is(err, sql.ErrNoRows) {
+			email = normalizeEmail(claims.Email)
+		}
+	}
+
+	// Создаем пользователя без известного ему пароля
+	userID, err := s.userService.CreateExternalUser(ctx, login, email)
+	if err != nil {
+		return 0, err
+	}
+
+	// Email уже подтвержден провайдером
+	if email != "" {
+		_ = s.userRepository.SetUserEmailVerified(ctx, userID, email)
+	}
+
+	// Привязываем аккаунт, при ошибке удаляем созданного пользователя
+	err = s.linkIdentity(ctx, userID, claims)
+	if err != nil {
+		_ = s.userRepository.DeleteUser(ctx, userID)
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// Логин из preferred_username или email, при совпадении с существующим добавляется случайный суффикс
+
+func (s *oidcServiceImpl) generateLogin(ctx context.Context, claims *entities.OIDCIDTokenClaims) (string, error) {
+	base := sanitizeLogin(claims.PreferredUsername)
+	if base == "" {
+		base = sanitizeLogin(strings.Split(claims.Email, "@")[0])
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	login := base
+	for attempt := 0; attempt < 5; attempt++ {
+		_, err := s.userRepository.GetUserByLogin(ctx, login)
+		if errors.Is(err, sql.ErrNoRows) {
+			return login, nil
+		} else if err != nil {
+			return "", err
+		}
+
+		login = fmt.Sprintf("%s_%s", base, utils.GenerateTokenID()[:6])
+	}
+
+	return "", fmt.Errorf("can't generate unique login")
+}
+
+func sanitizeLogin(value string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			builder.WriteRune(r)
+		}
+		if builder.Len() >= oidcLoginMaxLength-7 {
+			break
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+type ProfileService interface {
+	GetUserProfile(ctx context.Context, userID int) (*entities.UserProfile, error)
+	UpdateUserProfile(ctx context.Context, userID int, profileUpdateRequest *entities.UserProfileUpdateRequest, clientInfo *entities.ClientInfo) (*entities.UserProfile, error)
+	UploadAvatar(ctx context.Context, userID int, avatar *multipart.FileHeader, clientInfo *entities.ClientInfo) (*entities.AvatarUploadResponse, error)
+	DeleteAvatar(ctx context.Context, userID int, clientInfo *entities.ClientInfo) error
+}
+
+type profileServiceImpl struct {
+	profileRepository repositories.ProfileRepository
+	auditLogger       AuditLogger
+}
+
+func NewProfileService(profileRepository repositories.ProfileRepository, auditLogger AuditLogger) ProfileService {
+	return &profileServiceImpl{profileRepository: profileRepository, auditLogger: auditLogger}
+}
+
+func (s *profileServiceImpl) GetUserProfile(ctx context.Context, userID int) (*entities.UserProfile, error) {
+
+	// Получаем профиль пользователя
+	userProfile, err := s.profileRepository.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user profile error: %w", err)
+	}
+
+	return userProfile, nil
+}
+
+func (s *profileServiceImpl) UpdateUserProfile(ctx context.Context, userID int, profileUpdateRequest *entities.UserProfileUpdateRequest, clientInfo *entities.ClientInfo) (*entities.UserProfile, error) {
+
+	// Проверяем поля профиля
+	err := validateProfileField(profileUpdateRequest.DisplayName, "display_name", entities.ProfileDisplayNameMaxLength)
+	if err == nil {
+		err = validateProfileField(profileUpdateRequest.Bio, "bio", entities.ProfileBioMaxLength)
+	}
+	if err == nil {
+		err = validateProfileField(profileUpdateRequest.Location, "location", entities.ProfileLocationMaxLength)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update user profile error: %w", err)
+	}
+
+	// Обновляем профиль и записываем результат в журнал аудита
+	err = s.profileRepository.UpdateUserProfile(ctx, userID, profileUpdateRequest)
+	if err != nil {
+		err = fmt.Errorf("update user profile error: %w", err)
+	}
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionUpdateProfile, entities.AuditTargetUser, strconv.Itoa(userID), err))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUserProfile(ctx, userID)
+}
+
+func (s *profileServiceImpl) UploadAvatar(ctx context.Context, userID int, avatar *multipart.FileHeader, clientInfo *entities.ClientInfo) (*entities.AvatarUploadResponse, error) {
+
+	// Проверяем размер файла
+	err := utils.CheckFileSize(avatar, entities.AvatarMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("upload avatar error: %w", err)
+	}
+
+	// Проверяем тип файла
+	if !utils.IsImageFile(avatar) {
+		return nil, fmt.Errorf("upload avatar error: file must be an image file (jpg, png, webp)")
+	}
+
+	// Загружаем аватар и записываем результат в журнал аудита
+	avatarUrl, err := s.uploadAvatar(ctx, userID, avatar)
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionUpdateAvatar, entities.AuditTargetUser, strconv.Itoa(userID), err))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.AvatarUploadResponse{AvatarUrl: avatarUrl}, nil
+}
+
+func (s *profileServiceImpl) uploadAvatar(ctx context.Context, userID int, avatar *multipart.FileHeader) (string, error) {
+
+	// Открываем файл
+	fileReader, err := avatar.Open()
+	if err != nil {
+		return "", fmt.Errorf("upload avatar error: failed to open file")
+	}
+	defer fileReader.Close()
+
+	// Загружаем аватар, предыдущий удаляется
+	avatarUrl, err := s.profileRepository.UploadAvatar(ctx, userID, &entities.AvatarUploadRequest{
+		File:     fileReader,
+		FileName: avatar.Filename,
+		FileSize: avatar.Size,
+		MimeType: avatar.Header.Get("Content-Type"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload avatar error: %w", err)
+	}
+
+	return avatarUrl, nil
+}
+
+func (s *profileServiceImpl) DeleteAvatar(ctx context.Context, userID int, clientInfo *entities.ClientInfo) error {
+
+	// Удаляем аватар и записываем результат в журнал аудита
+	err := s.profileRepository.DeleteAvatar(ctx, userID)
+	if err != nil {
+		err = fmt.Errorf("delete avatar error: %w", err)
+	}
+	s.auditLogger.Log(ctx, newAuditEvent(userID, clientInfo, entities.AuditActionDeleteAvatar, entities.AuditTargetUser, strconv.Itoa(userID), err))
+
+	return err
+}
+
+// Проверка поля профиля: пробелы по краям убираются, длина считается в символах
+
+func validateProfileField(value *string, name string, maxLength int) error {
+	if value == nil {
+		return nil
+	}
+
+	*value = strings.TrimSpace(*value)
+	if !utf8.ValidString(*value) {
+		return fmt.Errorf("%s must be valid utf-8", name)
+	}
+	if utf8.RuneCountInString(*value) > maxLength {
+		return fmt.Errorf("%s must be at most %d characters", name, maxLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+	"github.com/unwelcome/iqjtest/internal/repositories"
+	"github.com/unwelcome/iqjtest/pkg/utils"
+)
+
+// Количество кодов восстановления, выдаваемых при включении 2FA
+const recoveryCodesCount = 10
+
+type TwoFactorService interface {
+	EnrollTwoFactor(ctx context.Context, userID int) (*entities.TwoFactorEnrollResponse, error)
+	ConfirmTwoFactor(ctx context.Context, userID int, twoFactorCodeRequest *entities.TwoFactorCodeRequest) (*entities.TwoFactorConfirmResponse, error)
+	DisableTwoFactor(ctx context.Context, userID int, twoFactorCodeRequest *entities.TwoFactorCodeRequest) error
+	IsTwoFactorEnabled(ctx context.Context, userID int) (bool, error)
+	VerifyTwoFactorCode(ctx context.Context, userID int, code string) (bool, error)
+}
+
+type twoFactorServiceImpl struct {
+	twoFactorRepository repositories.TwoFactorRepository
+	userRepository      repositories.UserRepository
+	tokenRepository     repositories.AuthRepository
+	loginProtection     *entities.LoginProtectionPolicy
+	issuer              string
+}
+
+func NewTwoFactorService(twoFactorRepository repositories.TwoFactorRepository, userRepository repositories.UserRepository, tokenRepository repositories.AuthRepository, loginProtection *entities.LoginProtectionPolicy, issuer string) TwoFactorService {
+	return &twoFactorServiceImpl{
+		twoFactorRepository: twoFactorRepository,
+		userRepository:      userRepository,
+		tokenRepository:     tokenRepository,
+		loginProtection:     loginProtection,
+		issuer:              issuer,
+	}
+}
+
+func (s *twoFactorServiceImpl) EnrollTwoFactor(ctx context.Context, userID int) (*entities.TwoFactorEnrollResponse, error) {
+
+	// Получаем логин пользователя для подписи аккаунта в приложении
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("enroll two-factor error: %w", err)
+	}
+
+	// Генерируем и сохраняем новый секрет
+	secret := utils.GenerateTOTPSecret()
+	err = s.twoFactorRepository.SetTOTPSecret(ctx, userID, secret)
+	if err != nil {
+		return nil, fmt.Errorf("enroll two-factor error: %w", err)
+	}
+
+	return &entities.TwoFactorEnrollResponse{Secret: secret, OTPAuthURI: utils.GetTOTPURI(s.issuer, user.Login, secret)}, nil
+}
+
+func (s *twoFactorServiceImpl) ConfirmTwoFactor(ctx context.Context, userID int, twoFactorCodeRequest *entities.TwoFactorCodeRequest) (*entities.TwoFactorConfirmResponse, error) {
+
+	// Получаем настройки 2FA пользователя
+	twoFactor, err := s.twoFactorRepository.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("confirm two-factor error: %w", err)
+	}
+	if twoFactor.Enabled {
+		return nil, fmt.Errorf("confirm two-factor error: two-factor authentication already enabled")
+	}
+	if twoFactor.Secret == "" {
+		return nil, fmt.Errorf("confirm two-factor error: enrollment not started")
+	}
+
+	// Проверяем код из приложения, так пользователь подтверждает, что сохранил секрет
+	ok, err := s.verifyTOTP(ctx, userID, twoFactor.Secret, twoFactorCodeRequest.Code)
+	if err != nil {
+		return nil, fmt.Errorf("confirm two-factor error: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("confirm two-factor error: invalid code")
+	}
+
+	// Генерируем коды восстановления, в бд храним только их хеши
+	recoveryCodes := make([]string, recoveryCodesCount)
+	recoveryCodeHashes := make([]string, recoveryCodesCount)
+	for i := range recoveryCodes {
+		code := utils.GenerateTokenID()[:10]
+		recoveryCodes[i] = code[:5] + "-" + code[5:]
+		recoveryCodeHashes[i] = utils.HashToken(code)
+	}
+
+	// Включаем 2FA
+	err = s.twoFactorRepository.EnableTwoFactor(ctx, userID, recoveryCodeHashes)
+	if err != nil {
+		return nil, fmt.Errorf("confirm two-factor error: %w", err)
+	}
+
+	return &entities.TwoFactorConfirmResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *twoFactorServiceImpl) DisableTwoFactor(ctx context.Context, userID int, twoFactorCodeRequest *entities.TwoFactorCodeRequest) error {
+
+	// Перебор кодов ограничен так же, как перебор паролей при входе
+	limitValue := strconv.Itoa(userID)
+	lockTTL, err := s.tokenRepository.GetLoginLock(ctx, "2fa", limitValue)
+	if err != nil {
+		return fmt.Errorf("disable two-factor error: %w", err)
+	}
+	if lockTTL > 0 {
+		return &entities.LoginLockedError{RetryAfter: lockTTL}
+	}
+
+	// Отключение требует действующий код, чтобы украденная сессия не могла снять защиту
+	ok, err := s.VerifyTwoFactorCode(ctx, userID, twoFactorCodeRequest.Code)
+	if err != nil {
+		return fmt.Errorf("disable two-factor error: %w", err)
+	}
+	if !ok {
+		return s.registerFailedCode(ctx, limitValue, fmt.Errorf("disable two-factor error: %w", entities.ErrInvalidTwoFactorCode))
+	}
+
+	// Сбрасываем счетчик неудачных попыток (если не получилось - не критично)
+	_ = s.tokenRepository.ResetFailedLoginAttempts(ctx, "2fa", limitValue)
+
+	// Выключаем 2FA
+	err = s.twoFactorRepository.DisableTwoFactor(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("disable two-factor error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *twoFactorServiceImpl) IsTwoFactorEnabled(ctx context.Context, userID int) (bool, error) {
+
+	// Получаем настройки 2FA пользователя
+	twoFactor, err := s.twoFactorRepository.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("get two-factor error: %w", err)
+	}
+
+	return twoFactor.Enabled, nil
+}
+
+func (s *twoFactorServiceImpl) VerifyTwoFactorCode(ctx context.Context, userID int, code string) (bool, error) {
+
+	// Получаем настройки 2FA пользователя
+	twoFactor, err := s.twoFactorRepository.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !twoFactor.Enabled {
+		return false, fmt.Errorf("two-factor authentication not enabled")
+	}
+
+	// Нормализуем код: убираем пробелы и дефисы
+	code = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+
+	// Проверяем TOTP код
+	ok, err := s.verifyTOTP(ctx, userID, twoFactor.Secret, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	// Проверяем код восстановления
+	return s.twoFactorRepository.UseRecoveryCode(ctx, userID, utils.HashToken(code))
+}
+
+// Учет неверного кода, при превышении лимита проверка кодов блокируется с экспоненциально растущим временем
+
+func (s *twoFactorServiceImpl) registerFailedCode(ctx context.Context, limitValue string, codeErr error) error {
+
+	// Увеличиваем счетчик неудачных попыток
+	attempts, err := s.tokenRepository.AddFailedLoginAttempt(ctx, "2fa", limitValue, s.loginProtection.AttemptsWindow)
+	if err != nil || attempts < s.loginProtection.MaxAttemptsPerLogin {
+		return codeErr
+	}
+
+	// Блокируем проверку кодов
+	lockDuration := getLockoutDuration(s.loginProtection, attempts, s.loginProtection.MaxAttemptsPerLogin)
+	err = s.tokenRepository.LockLogin(ctx, "2fa", limitValue, lockDuration)
+	if err != nil {
+		return codeErr
+	}
+
+	return &entities.LoginLockedError{RetryAfter: lockDuration}
+}
+
+// Проверка TOTP кода, каждый код принимается только один раз
+
+func (s *twoFactorServiceImpl) verifyTOTP(ctx context.Context, userID int, secret, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	return s.twoFactorRepository.UpdateTOTPLastStep(ctx, userID, step)
+}
@@ -2,51 +2,113 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/unwelcome/iqjtest/internal/entities"
 	"github.com/unwelcome/iqjtest/internal/repositories"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/unwelcome/iqjtest/pkg/hasher"
+	"github.com/unwelcome/iqjtest/pkg/policy"
+	"github.com/unwelcome/iqjtest/pkg/utils"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, userCreate *entities.UserCreateRequest) (int, error)
+	CreateExternalUser(ctx context.Context, login, email string) (int, error)
 	LoginUser(ctx context.Context, userLogin *entities.UserLoginRequest) (int, error)
+	GetUserByLogin(ctx context.Context, login string) (*entities.User, error)
 	GetUserByID(ctx context.Context, userID int) (*entities.UserGet, error)
-	GetAllUsers(ctx context.Context) ([]*entities.UserGet, error)
+	GetUserLogin(ctx context.Context, userID int) (string, error)
+	GetAllUsers(ctx context.Context, filter *entities.UserListFilter) (*entities.UserListResponse, error)
+	CheckUserPassword(ctx context.Context, userID int, password string) error
+	ValidateUserPassword(ctx context.Context, userID int, password string) error
 	UpdateUserPassword(ctx context.Context, userID int, userUpdatePasswordRequest *entities.UserUpdatePasswordRequest) error
-	DeleteUser(ctx context.Context, userID int) error
+	UpdateUserRole(ctx context.Context, userID int, userUpdateRoleRequest *entities.UserUpdateRoleRequest) error
+	ScheduleUserDeletion(ctx context.Context, userID, requestedBy int, gracePeriod time.Duration) (*entities.UserDeletion, error)
+	GetUserDeletion(ctx context.Context, userID int) (*entities.UserDeletion, error)
+	CancelUserDeletion(ctx context.Context, userID int) (bool, error)
 }
 
 type userServiceImpl struct {
-	userRepository repositories.UserRepository
-	bcryptCost     int
+	userRepository    repositories.UserRepository
+	passwordHasher    hasher.PasswordHasher
+	passwordValidator policy.PasswordValidator
+	dummyPasswordHash string
 }
 
-func NewUserService(userRepository repositories.UserRepository, bcryptCost int) UserService {
-	return &userServiceImpl{userRepository: userRepository, bcryptCost: bcryptCost}
+func NewUserService(userRepository repositories.UserRepository, passwordHasher hasher.PasswordHasher, passwordValidator policy.PasswordValidator) UserService {
+
+	// Хеш для сравнения, когда пользователь не найден: время ответа не должно выдавать существование логина
+	dummyPasswordHash, _ := passwordHasher.Hash("dummy-password")
+
+	return &userServiceImpl{
+		userRepository:    userRepository,
+		passwordHasher:    passwordHasher,
+		passwordValidator: passwordValidator,
+		dummyPasswordHash: dummyPasswordHash,
+	}
 }
 
 func (s *userServiceImpl) CreateUser(ctx context.Context, userCreate *entities.UserCreateRequest) (int, error) {
 
-	// Переводим пароль из строки в срез байт
-	bytePassword := []byte(userCreate.Password)
+	// Проверяем пароль по политике паролей
+	err := s.passwordValidator.Validate(userCreate.Password, userCreate.Login)
+	if err != nil {
+		return 0, fmt.Errorf("create user error: %w", err)
+	}
 
-	// Проверяем длину пароля, больше 72 байт библиотека не захеширует
-	if len(bytePassword) >= 70 {
-		return 0, fmt.Errorf("create user error: password too long")
+	// Проверяем email (необязательное поле)
+	userCreate.Email = normalizeEmail(userCreate.Email)
+	if userCreate.Email != "" && !validateEmail(userCreate.Email) {
+		return 0, fmt.Errorf("create user error: %w", entities.ErrInvalidEmail)
 	}
 
 	// Хешируем пароль
-	passwordHash, err := bcrypt.GenerateFromPassword(bytePassword, s.bcryptCost)
+	passwordHash, err := s.passwordHasher.Hash(userCreate.Password)
 	if err != nil {
 		return 0, fmt.Errorf("create user error: %w", err)
 	}
 
 	// Создаем пользователя
-	user := &entities.User{Login: userCreate.Login, PasswordHash: string(passwordHash)}
+	user := &entities.User{Login: userCreate.Login, Email: userCreate.Email, PasswordHash: passwordHash}
+
+	// Добавляем пользователя в бд (с кодом приглашения - вместе с его использованием)
+	if userCreate.InvitationCode != "" {
+		err = s.userRepository.CreateUserWithInvitation(ctx, user, utils.HashToken(userCreate.InvitationCode))
+	} else {
+		err = s.userRepository.CreateUser(ctx, user)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("create user error: %w", err)
+	}
+
+	return user.ID, nil
+}
+
+// Создание пользователя, вошедшего через внешнего провайдера.
+// Пароль случайный и пользователю неизвестен, установить свой можно через сброс пароля
+
+func (s *userServiceImpl) CreateExternalUser(ctx context.Context, login, email string) (int, error) {
+
+	// Проверяем email
+	email = normalizeEmail(email)
+	if email != "" && !validateEmail(email) {
+		return 0, fmt.Errorf("create user error: %w", entities.ErrInvalidEmail)
+	}
+
+	// Хешируем случайный пароль
+	passwordHash, err := s.passwordHasher.Hash(utils.GenerateTokenID() + utils.GenerateTokenID())
+	if err != nil {
+		return 0, fmt.Errorf("create user error: %w", err)
+	}
 
 	// Добавляем пользователя в бд
+	user := &entities.User{Login: login, Email: email, PasswordHash: passwordHash}
 	err = s.userRepository.CreateUser(ctx, user)
 	if err != nil {
 		return 0, fmt.Errorf("create user error: %w", err)
@@ -59,18 +121,45 @@ func (s *userServiceImpl) LoginUser(ctx context.Context, userLogin *entities.Use
 
 	// Получаем пользователя с данным логином
 	userWithLogin, err := s.userRepository.GetUserByLogin(ctx, userLogin.Login)
-	if err != nil {
-		return 0, fmt.Errorf("login user error: user not found")
+	if errors.Is(err, sql.ErrNoRows) {
+		// Все равно сравниваем пароль, чтобы время ответа не отличалось
+		_, _ = s.passwordHasher.Verify(userLogin.Password, s.dummyPasswordHash)
+		return 0, fmt.Errorf("login user error: %w", entities.ErrInvalidCredentials)
+	} else if err != nil {
+		return 0, fmt.Errorf("login user error: %w", err)
 	}
 
 	// Проверяем пароль
-	if bcrypt.CompareHashAndPassword([]byte(userWithLogin.PasswordHash), []byte(userLogin.Password)) != nil {
-		return 0, fmt.Errorf("login user error: invalid password")
+	ok, err := s.passwordHasher.Verify(userLogin.Password, userWithLogin.PasswordHash)
+	if err != nil {
+		return 0, fmt.Errorf("login user error: %w", err)
+	}
+	if !ok {
+		return 0, fmt.Errorf("login user error: %w", entities.ErrInvalidCredentials)
+	}
+
+	// Хеш создан устаревшим алгоритмом или с устаревшими параметрами - пересоздаем его, пока известен пароль
+	// (если не получилось - не критично, попробуем при следующем входе)
+	if s.passwordHasher.NeedsRehash(userWithLogin.PasswordHash) {
+		if passwordHash, err := s.passwordHasher.Hash(userLogin.Password); err == nil {
+			_ = s.userRepository.UpdateUserPassword(ctx, userWithLogin.ID, passwordHash)
+		}
 	}
 
 	return userWithLogin.ID, nil
 }
 
+func (s *userServiceImpl) GetUserByLogin(ctx context.Context, login string) (*entities.User, error) {
+
+	// Получаем пользователя по логину
+	user, err := s.userRepository.GetUserByLogin(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("get user by login error: %w", err)
+	}
+
+	return user, nil
+}
+
 func (s *userServiceImpl) GetUserByID(ctx context.Context, userID int) (*entities.UserGet, error) {
 
 	// Получаем пользователя по ID
@@ -82,27 +171,133 @@ func (s *userServiceImpl) GetUserByID(ctx context.Context, userID int) (*entitie
 	return user, nil
 }
 
-func (s *userServiceImpl) GetAllUsers(ctx context.Context) ([]*entities.UserGet, error) {
+func (s *userServiceImpl) GetUserLogin(ctx context.Context, userID int) (string, error) {
+
+	// Получаем логин пользователя, в том числе помеченного на удаление
+	login, err := s.userRepository.GetUserLogin(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("get user login error: %w", err)
+	}
+
+	return login, nil
+}
+
+func (s *userServiceImpl) GetAllUsers(ctx context.Context, filter *entities.UserListFilter) (*entities.UserListResponse, error) {
+
+	// Проверяем количество пользователей
+	if filter.Limit == 0 {
+		filter.Limit = entities.UsersDefaultLimit
+	}
+	if filter.Limit < 0 || filter.Limit > entities.UsersMaxLimit {
+		return nil, fmt.Errorf("get all users error: %w", entities.NewValidationError("limit must be between 1 and %d", entities.UsersMaxLimit))
+	}
+
+	// Проверяем порядок сортировки (по умолчанию новые первыми)
+	if filter.Order == "" {
+		filter.Order = entities.SortOrderDesc
+	}
+	if filter.Order != entities.SortOrderAsc && filter.Order != entities.SortOrderDesc {
+		return nil, fmt.Errorf("get all users error: %w", entities.NewValidationError("invalid order"))
+	}
+
+	// Проверяем способ поиска
+	if filter.SearchMode == "" {
+		filter.SearchMode = entities.UserSearchPrefix
+	}
+	if filter.SearchMode != entities.UserSearchPrefix && filter.SearchMode != entities.UserSearchContains {
+		return nil, fmt.Errorf("get all users error: %w", entities.NewValidationError("invalid search_mode"))
+	}
+
+	// Парсим курсор: created_at и ID последнего пользователя предыдущей страницы
+	if filter.Cursor != "" {
+		values, err := utils.DecodeCursor(filter.Cursor, 2)
+		if err == nil {
+			filter.CursorCreatedAt, err = time.Parse(time.RFC3339Nano, values[0])
+		}
+		if err == nil {
+			filter.CursorID, err = strconv.Atoi(values[1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get all users error: %w", entities.NewValidationError("invalid cursor"))
+		}
+	}
+
+	// Получаем пользователей
+	users, err := s.userRepository.GetAllUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get all users error: %w", err)
+	}
 
-	// Получаем всех пользователей
-	users, err := s.userRepository.GetAllUsers(ctx)
+	// Считаем пользователей по фильтру
+	total, err := s.userRepository.CountUsers(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("get all users error: %w", err)
 	}
 
-	return users, nil
+	// Следующая страница начинается после последнего пользователя текущей
+	response := &entities.UserListResponse{Users: users, Total: total}
+	if len(users) > filter.Limit {
+		response.Users = users[:filter.Limit]
+		last := response.Users[filter.Limit-1]
+		response.NextCursor = utils.EncodeCursor(last.CreatedAt, strconv.Itoa(last.ID))
+	}
+
+	return response, nil
+}
+
+func (s *userServiceImpl) CheckUserPassword(ctx context.Context, userID int, password string) error {
+
+	// Получаем хеш пароля пользователя
+	passwordHash, err := s.userRepository.GetUserPasswordHash(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("check password error: %w", err)
+	}
+
+	// Проверяем пароль
+	ok, err := s.passwordHasher.Verify(password, passwordHash)
+	if err != nil {
+		return fmt.Errorf("check password error: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("check password error: %w", entities.ErrInvalidCredentials)
+	}
+
+	return nil
+}
+
+func (s *userServiceImpl) ValidateUserPassword(ctx context.Context, userID int, password string) error {
+
+	// Получаем логин пользователя для проверки пароля (сброс пароля доступен и пользователю, помеченному на удаление)
+	login, err := s.userRepository.GetUserLogin(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("validate password error: %w", err)
+	}
+
+	// Проверяем пароль по политике паролей
+	err = s.passwordValidator.Validate(password, login)
+	if err != nil {
+		return fmt.Errorf("validate password error: %w", err)
+	}
+
+	return nil
 }
 
 func (s *userServiceImpl) UpdateUserPassword(ctx context.Context, userID int, userUpdatePasswordRequest *entities.UserUpdatePasswordRequest) error {
 
+	// Проверяем пароль по политике паролей
+	err := s.ValidateUserPassword(ctx, userID, userUpdatePasswordRequest.Password)
+	if err != nil {
+		return fmt.Errorf("update user password error: %w", err)
+	}
+
 	// Хешируем новый пароль
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(userUpdatePasswordRequest.Password), s.bcryptCost)
+	passwordHash, err := s.passwordHasher.Hash(userUpdatePasswordRequest.Password)
 	if err != nil {
 		return fmt.Errorf("update user password error: %w", err)
 	}
 
 	// Обновляем пароль
-	err = s.userRepository.UpdateUserPassword(ctx, userID, string(passwordHash))
+	err = s.userRepository.UpdateUserPassword(ctx, userID, passwordHash)
 	if err != nil {
 		return fmt.Errorf("update user password error: %w", err)
 	}
@@ -110,13 +305,64 @@ func (s *userServiceImpl) UpdateUserPassword(ctx context.Context, userID int, us
 	return nil
 }
 
-func (s *userServiceImpl) DeleteUser(ctx context.Context, userID int) error {
+func (s *userServiceImpl) UpdateUserRole(ctx context.Context, userID int, userUpdateRoleRequest *entities.UserUpdateRoleRequest) error {
+
+	// Проверяем роль
+	if !entities.IsValidRole(userUpdateRoleRequest.Role) {
+		return fmt.Errorf("update user role error: invalid role")
+	}
 
-	// Удаляем пользователя
-	err := s.userRepository.DeleteUser(ctx, userID)
+	// Обновляем роль
+	err := s.userRepository.UpdateUserRole(ctx, userID, userUpdateRoleRequest.Role)
 	if err != nil {
-		return fmt.Errorf("delete user error: %w", err)
+		return fmt.Errorf("update user role error: %w", err)
 	}
 
 	return nil
 }
+
+func (s *userServiceImpl) ScheduleUserDeletion(ctx context.Context, userID, requestedBy int, gracePeriod time.Duration) (*entities.UserDeletion, error) {
+
+	// Помечаем пользователя на удаление, данные удалит фоновая очистка после gracePeriod
+	userDeletion, err := s.userRepository.ScheduleUserDeletion(ctx, userID, requestedBy, gracePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("delete user error: %w", err)
+	}
+
+	return userDeletion, nil
+}
+
+func (s *userServiceImpl) GetUserDeletion(ctx context.Context, userID int) (*entities.UserDeletion, error) {
+
+	// Получаем запрос на удаление пользователя
+	userDeletion, err := s.userRepository.GetUserDeletion(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user deletion error: %w", err)
+	}
+
+	return userDeletion, nil
+}
+
+func (s *userServiceImpl) CancelUserDeletion(ctx context.Context, userID int) (bool, error) {
+
+	// Снимаем пометку на удаление
+	cancelled, err := s.userRepository.CancelUserDeletion(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("cancel user deletion error: %w", err)
+	}
+
+	return cancelled, nil
+}
+
+// Нормализация email: адреса, отличающиеся только регистром, считаются одним адресом
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Проверка формата email
+
+func validateEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
@@ -0,0 +1,54 @@
+package hasher
+
+import (
+	"fmt"
+)
+
+// Хеширование паролей. Хеш содержит префикс с алгоритмом и параметрами ($argon2id$..., $2a$...),
+// поэтому хеши разных алгоритмов могут храниться вместе
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
+
+// Алгоритм хеширования, распознающий свои хеши по префиксу
+
+type Algorithm interface {
+	PasswordHasher
+	Supports(encodedHash string) bool
+}
+
+type passwordHasherImpl struct {
+	primary    Algorithm
+	algorithms []Algorithm
+}
+
+// Новые пароли хешируются алгоритмом primary, проверка поддерживает primary и legacy алгоритмы
+
+func NewPasswordHasher(primary Algorithm, legacy ...Algorithm) PasswordHasher {
+	return &passwordHasherImpl{primary: primary, algorithms: append([]Algorithm{primary}, legacy...)}
+}
+
+func (h *passwordHasherImpl) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *passwordHasherImpl) Verify(password, encodedHash string) (bool, error) {
+
+	// Проверяем пароль алгоритмом, которым создан хеш
+	for _, algorithm := range h.algorithms {
+		if algorithm.Supports(encodedHash) {
+			return algorithm.Verify(password, encodedHash)
+		}
+	}
+
+	return false, fmt.Errorf("unknown password hash format")
+}
+
+// Хеш нужно пересоздать, если он создан другим алгоритмом или с устаревшими параметрами
+
+func (h *passwordHasherImpl) NeedsRehash(encodedHash string) bool {
+	return !h.primary.Supports(encodedHash) || h.primary.NeedsRehash(encodedHash)
+}
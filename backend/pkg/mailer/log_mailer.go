@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type logMailerImpl struct {
+	logger   zerolog.Logger
+	filePath string
+	mutex    sync.Mutex
+}
+
+// Письма не отправляются, а пишутся в лог и (если указан файл) дописываются в файл. Для локальной разработки
+
+func NewLogMailer(logger zerolog.Logger, filePath string) Mailer {
+	return &logMailerImpl{logger: logger, filePath: filePath}
+}
+
+func (m *logMailerImpl) Send(ctx context.Context, mail *entities.Mail) error {
+	m.logger.Info().Str("to", mail.To).Str("subject", mail.Subject).Str("body", mail.Body).Msg("Mail sent")
+
+	if m.filePath == "" {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Дописываем письмо в конец файла
+	file, err := os.OpenFile(m.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mail file error: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), mail.To, mail.Subject, mail.Body)
+	if err != nil {
+		return fmt.Errorf("write mail file error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// Отправка писем пользователям
+
+type Mailer interface {
+	Send(ctx context.Context, mail *entities.Mail) error
+}
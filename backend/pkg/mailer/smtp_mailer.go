@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+type smtpMailerImpl struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// Отправка писем через SMTP сервер (без авторизации, если username пустой - например, MailHog)
+
+func NewSMTPMailer(host, port, username, password, from string) Mailer {
+	return &smtpMailerImpl{host: host, port: port, username: username, password: password, from: from}
+}
+
+func (m *smtpMailerImpl) Send(ctx context.Context, mail *entities.Mail) error {
+
+	// Подключаемся к серверу с учетом времени выполнения запроса
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, m.port))
+	if err != nil {
+		return fmt.Errorf("smtp connect error: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp connect error: %w", err)
+	}
+	defer client.Close()
+
+	// Включаем шифрование, если сервер его поддерживает
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("smtp starttls error: %w", err)
+		}
+	}
+
+	// Авторизуемся
+	if m.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("smtp auth error: %w", err)
+		}
+	}
+
+	// Указываем отправителя и получателя
+	if err = client.Mail(m.from); err != nil {
+		return fmt.Errorf("smtp sender error: %w", err)
+	}
+	if err = client.Rcpt(mail.To); err != nil {
+		return fmt.Errorf("smtp recipient error: %w", err)
+	}
+
+	// Передаем письмо
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data error: %w", err)
+	}
+	if _, err = writer.Write(m.buildMessage(mail)); err != nil {
+		return fmt.Errorf("smtp write error: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("smtp write error: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m *smtpMailerImpl) buildMessage(mail *entities.Mail) []byte {
+	var builder strings.Builder
+
+	builder.WriteString("From: " + m.from + "\r\n")
+	builder.WriteString("To: " + mail.To + "\r\n")
+	builder.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", mail.Subject) + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+
+	return []byte(builder.String())
+}
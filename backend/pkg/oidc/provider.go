@@ -0,0 +1,292 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// Клиент OpenID Connect провайдера (authorization code flow с PKCE)
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier string) (*entities.OIDCTokenResponse, error)
+	VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*entities.OIDCIDTokenClaims, error)
+}
+
+// Время, в течение которого используется полученный discovery документ
+const discoveryCacheLifetime = time.Hour
+
+// Минимальный интервал между загрузками JWKS при встрече неизвестного kid
+const jwksRefreshInterval = time.Minute
+
+// Допустимое расхождение часов с провайдером
+const clockSkew = time.Minute
+
+// Алгоритмы подписи ID токена (симметричные алгоритмы не поддерживаются)
+var idTokenSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type providerImpl struct {
+	name         string
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu                 sync.Mutex
+	discovery          *entities.OIDCDiscovery
+	discoveryFetchedAt time.Time
+	keys               map[string]any
+	keysFetchedAt      time.Time
+}
+
+func NewProvider(name, issuerURL, clientID, clientSecret, redirectURL string, scopes []string) Provider {
+	return &providerImpl{
+		name:         name,
+		issuerURL:    strings.TrimSuffix(issuerURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		scopes:       scopes,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *providerImpl) Name() string {
+	return p.name
+}
+
+// Ссылка на страницу входа провайдера
+
+func (p *providerImpl) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+
+	// Получаем адрес authorization endpoint
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	// Добавляем параметры запроса, сохраняя уже указанные в адресе
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", strings.Join(p.scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Обмен кода авторизации на токены
+
+func (p *providerImpl) Exchange(ctx context.Context, code, codeVerifier string) (*entities.OIDCTokenResponse, error) {
+
+	// Получаем адрес token endpoint
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Формируем запрос
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.clientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("token request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// Авторизуем клиента (client_secret_basic), публичный клиент передает только client_id
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Парсим ответ
+	tokenResponse := &entities.OIDCTokenResponse{}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(tokenResponse); err != nil {
+		return nil, fmt.Errorf("token response error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResponse.Error != "" {
+		return nil, fmt.Errorf("token endpoint error: %s %s", tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	return tokenResponse, nil
+}
+
+// Проверка подписи и содержимого ID токена
+
+func (p *providerImpl) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*entities.OIDCIDTokenClaims, error) {
+
+	// Получаем issuer провайдера
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем подпись, issuer, audience и время действия токена
+	claims := &entities.OIDCIDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		return p.getKey(ctx, token)
+	},
+		jwt.WithValidMethods(idTokenSigningMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	// Токен должен быть выдан на этот запрос входа
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("invalid id token: nonce mismatch")
+	}
+
+	// Если токен выдан нескольким клиентам, он должен быть выдан для нас
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return nil, fmt.Errorf("invalid id token: azp mismatch")
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid id token: subject required")
+	}
+
+	return claims, nil
+}
+
+// Получение discovery документа (кешируется в памяти)
+
+func (p *providerImpl) getDiscovery(ctx context.Context) (*entities.OIDCDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discoveryFetchedAt) < discoveryCacheLifetime {
+		return p.discovery, nil
+	}
+
+	discovery := &entities.OIDCDiscovery{}
+	if err := p.getJSON(ctx, p.issuerURL+"/.well-known/openid-configuration", discovery); err != nil {
+		return nil, fmt.Errorf("discovery error: %w", err)
+	}
+
+	// Issuer документа должен совпадать с настроенным, иначе документ подменен
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuerURL {
+		return nil, fmt.Errorf("discovery error: issuer mismatch %q", discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery error: required endpoints missing")
+	}
+
+	p.discovery = discovery
+	p.discoveryFetchedAt = time.Now()
+
+	return discovery, nil
+}
+
+// Получение ключа для проверки подписи ID токена.
+// Если kid неизвестен - ключи провайдера загружаются заново (провайдер мог провести ротацию)
+
+func (p *providerImpl) getKey(ctx context.Context, token *jwt.Token) (any, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := p.lookupKey(kid)
+	if !ok && time.Since(p.keysFetchedAt) >= jwksRefreshInterval {
+		jwks := &entities.JWKS{}
+		if err = p.getJSON(ctx, discovery.JWKSURI, jwks); err != nil {
+			return nil, fmt.Errorf("jwks error: %w", err)
+		}
+
+		p.keys = parseJWKS(jwks)
+		p.keysFetchedAt = time.Now()
+
+		key, ok = p.lookupKey(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+// Поиск ключа по kid, токен без kid допустим только при единственном ключе провайдера
+
+func (p *providerImpl) lookupKey(kid string) (any, bool) {
+	if kid == "" {
+		if len(p.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *providerImpl) getJSON(ctx context.Context, url string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
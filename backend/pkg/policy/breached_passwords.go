@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Длина префикса SHA-1 хеша, по которому группируются хеши (как в k-anonymity API Pwned Passwords)
+const sha1PrefixLength = 5
+
+// Список утекших паролей
+
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
+
+type breachedPasswordsImpl struct {
+	// Префикс хеша -> отсортированные окончания хешей
+	suffixes map[string][]string
+	count    int
+}
+
+// Загрузка списка утекших паролей при старте приложения (пустой путь - проверка отключена)
+
+func InitBreachedPasswords(filePath string, l zerolog.Logger) BreachedPasswords {
+	if filePath == "" {
+		return nil
+	}
+
+	breached, err := LoadBreachedPasswords(filePath)
+	if err != nil {
+		l.Fatal().Err(err).Str("filePath", filePath).Msg("Failed to load breached passwords")
+	}
+
+	l.Trace().Int("hashesCount", breached.count).Msg("Breached passwords loaded")
+	return breached
+}
+
+// Загрузка файла с SHA-1 хешами паролей в формате Pwned Passwords: строка "HASH:COUNT" или "HASH".
+// Поддерживается и формат ответа range API: файл из блоков "PREFIX" и строк "SUFFIX:COUNT" под ним
+
+func LoadBreachedPasswords(filePath string) (*breachedPasswordsImpl, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open breached passwords file error: %w", err)
+	}
+	defer file.Close()
+
+	breached := &breachedPasswordsImpl{suffixes: make(map[string][]string)}
+
+	// Читаем файл построчно
+	prefix := ""
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+
+		switch {
+		case len(hash) == sha1PrefixLength && isHex(hash):
+			// Начало блока хешей с общим префиксом
+			prefix = hash
+		case len(hash) == sha1.Size*2 && isHex(hash):
+			breached.add(hash)
+		case len(hash) == sha1.Size*2-sha1PrefixLength && isHex(hash) && prefix != "":
+			breached.add(prefix + hash)
+		default:
+			return nil, fmt.Errorf("invalid breached passwords file line %d", lineNumber)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached passwords file error: %w", err)
+	}
+
+	// Сортируем окончания для бинарного поиска
+	for hashPrefix, suffixes := range breached.suffixes {
+		slices.Sort(suffixes)
+		breached.suffixes[hashPrefix] = slices.Compact(suffixes)
+		breached.count += len(breached.suffixes[hashPrefix])
+	}
+
+	return breached, nil
+}
+
+func (b *breachedPasswordsImpl) Contains(password string) bool {
+	hash := sha1.Sum([]byte(password))
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	_, found := slices.BinarySearch(b.suffixes[hexHash[:sha1PrefixLength]], hexHash[sha1PrefixLength:])
+	return found
+}
+
+func (b *breachedPasswordsImpl) add(hash string) {
+	b.suffixes[hash[:sha1PrefixLength]] = append(b.suffixes[hash[:sha1PrefixLength]], hash[sha1PrefixLength:])
+}
+
+func isHex(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// Проверка пароля по политике паролей
+
+type PasswordValidator interface {
+	Validate(password, login string) error
+}
+
+type passwordValidatorImpl struct {
+	policy   *entities.PasswordPolicy
+	breached BreachedPasswords
+}
+
+// breached == nil - проверка по списку утекших паролей отключена
+
+func NewPasswordValidator(policy *entities.PasswordPolicy, breached BreachedPasswords) PasswordValidator {
+	return &passwordValidatorImpl{policy: policy, breached: breached}
+}
+
+// Возвращает *entities.PasswordPolicyError со всеми нарушенными правилами или nil
+
+func (v *passwordValidatorImpl) Validate(password, login string) error {
+	var violations []*entities.PasswordPolicyViolation
+	violate := func(code, message string) {
+		violations = append(violations, &entities.PasswordPolicyViolation{Code: code, Message: message})
+	}
+
+	// Длина считается в символах, ограничение сверху - в байтах (защита от хеширования слишком длинных строк)
+	if utf8.RuneCountInString(password) < v.policy.MinLength {
+		violate(entities.PasswordTooShort, fmt.Sprintf("password must be at least %d characters long", v.policy.MinLength))
+	}
+	if len(password) > v.policy.MaxLength {
+		violate(entities.PasswordTooLong, fmt.Sprintf("password must be at most %d bytes long", v.policy.MaxLength))
+	}
+
+	// Классы символов
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if v.policy.RequireLowercase && !hasLower {
+		violate(entities.PasswordMissingLowercase, "password must contain a lowercase letter")
+	}
+	if v.policy.RequireUppercase && !hasUpper {
+		violate(entities.PasswordMissingUppercase, "password must contain an uppercase letter")
+	}
+	if v.policy.RequireDigit && !hasDigit {
+		violate(entities.PasswordMissingDigit, "password must contain a digit")
+	}
+	if v.policy.RequireSymbol && !hasSymbol {
+		violate(entities.PasswordMissingSymbol, "password must contain a symbol")
+	}
+
+	// Пароль не должен совпадать с логином
+	if v.policy.ForbidLogin && login != "" && strings.EqualFold(password, login) {
+		violate(entities.PasswordEqualsLogin, "password must not be equal to login")
+	}
+
+	// Пароль не должен встречаться в утечках
+	if v.policy.CheckBreached && v.breached != nil && v.breached.Contains(password) {
+		violate(entities.PasswordBreached, "password has appeared in a data breach, choose another one")
+	}
+
+	if len(violations) > 0 {
+		return &entities.PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// Получение данных клиента (устройства) из запроса
+
+func GetClientInfo(c *fiber.Ctx) *entities.ClientInfo {
+	impersonatorID, _ := c.Locals("actorID").(int)
+
+	return &entities.ClientInfo{
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		IP:             c.IP(),
+		ImpersonatorID: impersonatorID,
+	}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Курсор постраничной выдачи: значения ключа сортировки последней записи страницы, закодированные в base64url.
+// Для клиента курсор непрозрачен и передается обратно без изменений
+
+func EncodeCursor(values ...string) string {
+	data, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(cursor string, count int) ([]string, error) {
+
+	// Декодируем курсор
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	// Проверяем количество значений
+	var values []string
+	if err = json.Unmarshal(data, &values); err != nil || len(values) != count {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return values, nil
+}
+
+// Экранирование спецсимволов шаблона LIKE (используется с ESCAPE '\')
+
+func EscapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+	CSRFTokenHeader    = "X-CSRF-Token"
+	OIDCStateCookie    = "oidc_state"
+)
+
+// Настройки передачи токенов через cookie (nil - токены передаются в теле ответа и заголовке Authorization)
+
+type TokenCookieOptions struct {
+	Domain               string
+	Secure               bool
+	SameSite             string
+	AccessTokenLifetime  time.Duration
+	RefreshTokenLifetime time.Duration
+}
+
+// Установка cookie с токенами и CSRF токеном
+
+func SetTokenCookies(c *fiber.Ctx, tokenPair *entities.TokenPair, options *TokenCookieOptions) {
+	now := time.Now()
+
+	c.Cookie(options.newCookie(AccessTokenCookie, tokenPair.AccessToken, now.Add(options.AccessTokenLifetime), true))
+	c.Cookie(options.newCookie(RefreshTokenCookie, tokenPair.RefreshToken, now.Add(options.RefreshTokenLifetime), true))
+
+	// CSRF токен должен быть доступен из JS, чтобы клиент мог передать его в заголовке
+	c.Cookie(options.newCookie(CSRFTokenCookie, GenerateTokenID(), now.Add(options.RefreshTokenLifetime), false))
+}
+
+// Удаление cookie с токенами
+
+func ClearTokenCookies(c *fiber.Ctx, options *TokenCookieOptions) {
+	expired := time.Unix(0, 0)
+
+	c.Cookie(options.newCookie(AccessTokenCookie, "", expired, true))
+	c.Cookie(options.newCookie(RefreshTokenCookie, "", expired, true))
+	c.Cookie(options.newCookie(CSRFTokenCookie, "", expired, false))
+}
+
+// Получение access токена из cookie или заголовка Authorization
+
+func GetAccessToken(c *fiber.Ctx, options *TokenCookieOptions) (string, error) {
+
+	// В режиме cookie сначала проверяем cookie
+	if options != nil {
+		if accessToken := c.Cookies(AccessTokenCookie); accessToken != "" {
+			return accessToken, nil
+		}
+	}
+
+	// Получаем заголовок авторизации
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("authorization header required")
+	}
+
+	// Проверяем корректность заголовка
+	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
+		return "", fmt.Errorf("invalid authorization header format")
+	}
+
+	// Получаем токен из заголовка
+	return authHeader[7:], nil
+}
+
+// Получение API ключа из заголовка Authorization (формат "ApiKey <key>")
+
+func GetAPIKey(c *fiber.Ctx) (string, bool) {
+	authHeader := c.Get("Authorization")
+	if len(authHeader) < 7 || authHeader[:7] != "ApiKey " {
+		return "", false
+	}
+	return authHeader[7:], true
+}
+
+// Cookie с параметром state входа через OIDC: связывает callback с браузером, который начал вход.
+// SameSite=Lax, так как на callback браузер возвращается переходом со страницы провайдера
+
+func SetOIDCStateCookie(c *fiber.Ctx, state string, lifetime time.Duration, secure bool) {
+	c.Cookie(&fiber.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    state,
+		Path:     "/api/oidc",
+		Expires:  time.Now().Add(lifetime),
+		Secure:   secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func ClearOIDCStateCookie(c *fiber.Ctx, secure bool) {
+	c.Cookie(&fiber.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    "",
+		Path:     "/api/oidc",
+		Expires:  time.Unix(0, 0),
+		Secure:   secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func (o *TokenCookieOptions) newCookie(name, value string, expires time.Time, httpOnly bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/api",
+		Domain:   o.Domain,
+		Expires:  expires,
+		Secure:   o.Secure,
+		HTTPOnly: httpOnly,
+		SameSite: o.SameSite,
+	}
+}
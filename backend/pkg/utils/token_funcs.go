@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
@@ -10,46 +12,55 @@ import (
 
 // Генерация пары access и refresh токенов
 
-func CreateTokens(userID int, secretKey string, accessTokenLifetime, refreshTokenLifetime time.Duration, tokenID *int) (*entities.TokenPair, error) {
+func CreateTokens(subject *entities.TokenSubject, keyring *TokenKeyring, accessTokenLifetime, refreshTokenLifetime time.Duration) (*entities.TokenPair, error) {
 
 	// Генерируем access токен
-	accessToken, err := GenerateToken(userID, secretKey, entities.AccessTokenType, accessTokenLifetime, *tokenID)
-	*tokenID++
+	accessTokenID := GenerateTokenID()
+	accessToken, err := GenerateToken(subject, keyring, entities.AccessTokenType, accessTokenLifetime, accessTokenID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Генерируем refresh токен
-	refreshToken, err := GenerateToken(userID, secretKey, entities.RefreshTokenType, refreshTokenLifetime, *tokenID)
-	*tokenID++
+	refreshTokenID := GenerateTokenID()
+	refreshToken, err := GenerateToken(subject, keyring, entities.RefreshTokenType, refreshTokenLifetime, refreshTokenID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Возвращаем оба токена
-	return &entities.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+	return &entities.TokenPair{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		AccessTokenID:  accessTokenID,
+		RefreshTokenID: refreshTokenID,
+	}, nil
 }
 
 // Создание jwt токена
 
-func GenerateToken(userID int, secretKey string, tokenType string, tokenLifetime time.Duration, tokenID int) (string, error) {
+func GenerateToken(subject *entities.TokenSubject, keyring *TokenKeyring, tokenType string, tokenLifetime time.Duration, tokenID string) (string, error) {
 
 	// Время создания токена
 	now := time.Now()
 
 	// Создаем тело токена
 	claims := &entities.TokenClaims{
-		UserID: userID,
-		Type:   tokenType,
+		UserID:    subject.UserID,
+		SessionID: subject.SessionID,
+		FamilyID:  subject.FamilyID,
+		Role:      subject.Role,
+		Type:      tokenType,
+		Actor:     subject.Actor,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(tokenLifetime)),
-			ID:        fmt.Sprintf("token-%d", tokenID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        tokenID,
 		},
 	}
 
-	// Подписываем токен
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secretKey))
+	// Подписываем токен активным ключом
+	tokenString, err := keyring.SignToken(claims)
 	if err != nil {
 		return "", fmt.Errorf("generate token error: %w", err)
 	}
@@ -59,12 +70,10 @@ func GenerateToken(userID int, secretKey string, tokenType string, tokenLifetime
 
 // Парсинг jwt токена
 
-func ParseToken(tokenString string, secretKey string) (*entities.TokenClaims, error) {
+func ParseToken(tokenString string, keyring *TokenKeyring) (*entities.TokenClaims, error) {
 
-	// Подтверждаем подлинность токена
-	token, err := jwt.ParseWithClaims(tokenString, &entities.TokenClaims{}, func(token *jwt.Token) (any, error) {
-		return []byte(secretKey), nil
-	})
+	// Подтверждаем подлинность токена одним из ключей набора
+	token, err := jwt.ParseWithClaims(tokenString, &entities.TokenClaims{}, keyring.KeyFunc, jwt.WithValidMethods(keyring.Methods()))
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, fmt.Errorf("token expired")
@@ -80,6 +89,86 @@ func ParseToken(tokenString string, secretKey string) (*entities.TokenClaims, er
 	return nil, fmt.Errorf("invalid token")
 }
 
+// Генерация уникального ID токена (jti)
+
+func GenerateTokenID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return fmt.Sprintf("%x", bytes)
+}
+
+// Хеш одноразового токена, в кеше хранится только хеш, чтобы утечка кеша не давала доступа
+
+func HashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+// Ключ отозванного access токена
+
+func GetRevokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("revoked_token:%s", tokenID)
+}
+
+// Ключ отозванной сессии
+
+func GetRevokedSessionKey(sessionID string) string {
+	return fmt.Sprintf("revoked_session:%s", sessionID)
+}
+
+// Ключи семейства refresh токенов: текущий токен семейства и уже использованные токены
+
+func GetTokenFamilyKeys(familyID string) (string, string) {
+	return fmt.Sprintf("token_family:%s:current", familyID), fmt.Sprintf("token_family:%s:used", familyID)
+}
+
+// Ключи счетчика неудачных попыток входа и блокировки входа (scope - login или ip)
+
+func GetLoginAttemptsKeys(scope, value string) (string, string) {
+	return fmt.Sprintf("login_attempts:%s:%s", scope, value), fmt.Sprintf("login_lock:%s:%s", scope, value)
+}
+
+// Ключ одноразового токена (purpose - назначение токена), хранит ID пользователя
+
+func GetOneTimeTokenKey(purpose, tokenHash string) string {
+	return fmt.Sprintf("one_time_token:%s:%s", purpose, tokenHash)
+}
+
+// Ключ с хешем последнего выданного пользователю одноразового токена
+
+func GetUserOneTimeTokenKey(userID int, purpose string) string {
+	return fmt.Sprintf("user:%d:one_time_token:%s", userID, purpose)
+}
+
+// Ключ состояния входа через OIDC провайдера (хранится хеш параметра state)
+
+func GetOIDCStateKey(stateHash string) string {
+	return fmt.Sprintf("oidc_state:%s", stateHash)
+}
+
+// Ключ последней выгрузки данных пользователя
+
+func GetDataExportKey(userID int) string {
+	return fmt.Sprintf("user:%d:data_export", userID)
+}
+
+// Ключ блокировки сборки выгрузки данных пользователя, хранит ID собираемой выгрузки
+
+func GetDataExportBuildKey(userID int) string {
+	return fmt.Sprintf("user:%d:data_export_build", userID)
+}
+
+// Ключ окна запросов для политики ограничения частоты (subject - ip:{ip} или user:{id})
+
+func GetRateLimitKey(policy, subject string) string {
+	return fmt.Sprintf("rate_limit:%s:%s", policy, subject)
+}
+
+// Ключ хеша с сессиями пользователя
+
+func GetSessionsKey(userID int) string {
+	return fmt.Sprintf("user:%d:sessions", userID)
+}
+
 func GetTokenKey(userID int, tokenType string) string {
 	if tokenType == entities.AccessTokenType {
 		return fmt.Sprintf("user:%d:access_tokens", userID)
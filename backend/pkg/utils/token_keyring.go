@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"github.com/unwelcome/iqjtest/internal/entities"
+)
+
+// ID ключа, которым подписываются токены в режиме HS256
+const HMACKeyID = "hs256"
+
+type TokenKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+type TokenKeyring struct {
+	activeKey *TokenKey
+	keys      map[string]*TokenKey
+}
+
+// Инициализация набора ключей подписи токенов
+
+func InitTokenKeyring(keysDir, activeKeyID, secretKey string, l zerolog.Logger) *TokenKeyring {
+
+	// Если каталог с ключами не указан - подписываем токены симметричным секретом
+	if keysDir == "" {
+		if secretKey == "ultra-secret-key" {
+			l.Warn().Msg("JWT tokens are signed with the default secret, set JWT_SECRET or JWT_KEYS_DIR")
+		}
+		return NewHMACTokenKeyring(secretKey)
+	}
+
+	keyring, err := LoadTokenKeyring(keysDir, activeKeyID)
+	if err != nil {
+		l.Fatal().Err(err).Str("keysDir", keysDir).Msg("Failed to load JWT keys")
+	}
+
+	l.Trace().Str("activeKeyID", keyring.activeKey.ID).Int("keysCount", len(keyring.keys)).Msg("JWT keys loaded")
+	return keyring
+}
+
+// Набор ключей из одного симметричного секрета (HS256)
+
+func NewHMACTokenKeyring(secretKey string) *TokenKeyring {
+	key := &TokenKey{
+		ID:        HMACKeyID,
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   []byte(secretKey),
+		VerifyKey: []byte(secretKey),
+	}
+	return &TokenKeyring{activeKey: key, keys: map[string]*TokenKey{key.ID: key}}
+}
+
+// Загрузка ключей из PEM файлов каталога.
+// ID ключа - имя файла: {kid}.pem для приватного ключа, {kid}.pub.pem для публичного ключа,
+// который используется только для проверки токенов, выданных до ротации
+
+func LoadTokenKeyring(keysDir, activeKeyID string) (*TokenKeyring, error) {
+	files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("read keys dir error: %w", err)
+	}
+
+	keyring := &TokenKeyring{keys: make(map[string]*TokenKey)}
+
+	// Загружаем каждый ключ
+	for _, file := range files {
+		key, err := loadTokenKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("load key %s error: %w", filepath.Base(file), err)
+		}
+
+		// Приватный ключ важнее публичного с тем же ID
+		if existing, ok := keyring.keys[key.ID]; ok && existing.SignKey != nil {
+			continue
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	// Выбираем ключ для подписи новых токенов
+	activeKey, ok := keyring.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", activeKeyID)
+	}
+	if activeKey.SignKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeKeyID)
+	}
+	keyring.activeKey = activeKey
+
+	return keyring, nil
+}
+
+// Подпись токена активным ключом
+
+func (k *TokenKeyring) SignToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.activeKey.Method, claims)
+	token.Header["kid"] = k.activeKey.ID
+	return token.SignedString(k.activeKey.SignKey)
+}
+
+// Получение ключа для проверки подписи токена
+
+func (k *TokenKeyring) KeyFunc(token *jwt.Token) (any, error) {
+
+	// Если в токене указан kid - проверяем только этим ключом
+	if kid, ok := token.Header["kid"].(string); ok {
+		key, exists := k.keys[kid]
+		if !exists {
+			return nil, fmt.Errorf("unknown key id")
+		}
+		if key.Method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return key.VerifyKey, nil
+	}
+
+	// Иначе проверяем всеми ключами с подходящим алгоритмом
+	keySet := jwt.VerificationKeySet{}
+	for _, key := range k.keys {
+		if key.Method.Alg() == token.Method.Alg() {
+			keySet.Keys = append(keySet.Keys, key.VerifyKey)
+		}
+	}
+	if len(keySet.Keys) == 0 {
+		return nil, fmt.Errorf("unexpected signing method")
+	}
+
+	return keySet, nil
+}
+
+// Алгоритмы подписи всех ключей набора
+
+func (k *TokenKeyring) Methods() []string {
+	unique := make(map[string]bool)
+	var methods []string
+	for _, key := range k.keys {
+		if !unique[key.Method.Alg()] {
+			unique[key.Method.Alg()] = true
+			methods = append(methods, key.Method.Alg())
+		}
+	}
+	return methods
+}
+
+// Публичные ключи в формате JWKS (симметричные ключи не публикуются)
+
+func (k *TokenKeyring) JWKS() *entities.JWKS {
+	jwks := &entities.JWKS{Keys: []*entities.JWK{}}
+
+	for _, key := range k.keys {
+		jwk := &entities.JWK{Kid: key.ID, Alg: key.Method.Alg(), Use: "sig"}
+
+		switch publicKey := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	// Сортируем ключи по ID для стабильного ответа
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+func loadTokenKey(file string) (*TokenKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Декодируем PEM блок
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+
+	key := &TokenKey{ID: strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".pem"), ".pub")}
+
+	// Парсим ключ в зависимости от типа блока
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Определяем алгоритм по типу ключа
+	switch typedKey := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.SignKey, key.VerifyKey = jwt.SigningMethodRS256, typedKey, &typedKey.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.VerifyKey = jwt.SigningMethodRS256, typedKey
+	case ed25519.PrivateKey:
+		key.Method, key.SignKey, key.VerifyKey = jwt.SigningMethodEdDSA, typedKey, typedKey.Public()
+	case ed25519.PublicKey:
+		key.Method, key.VerifyKey = jwt.SigningMethodEdDSA, typedKey
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// Секрет из тестовых векторов RFC 6238 ("12345678901234567890" в base32)
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateHOTPTestVectors(t *testing.T) {
+	key, err := totpEncoding.DecodeString(testTOTPSecret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+
+	// Младшие 6 цифр 8-значных кодов из приложения B RFC 6238
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tt := range tests {
+		if code := generateHOTP(key, tt.unix/totpPeriod); code != tt.code {
+			t.Errorf("generateHOTP(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTPSkewWindow(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(testTOTPSecret)
+	now := time.Unix(1111111109, 0)
+	currentStep := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{name: "two steps behind", offset: -2, ok: false},
+		{name: "previous step", offset: -1, ok: true},
+		{name: "current step", offset: 0, ok: true},
+		{name: "next step", offset: 1, ok: true},
+		{name: "two steps ahead", offset: 2, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := generateHOTP(key, currentStep+tt.offset)
+			step, ok := ValidateTOTP(testTOTPSecret, code, now)
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != currentStep+tt.offset {
+				t.Errorf("ValidateTOTP step = %d, want %d", step, currentStep+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPReplayReturnsCodeStep(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(testTOTPSecret)
+	issuedAt := time.Unix(1111111109, 0)
+	issuedStep := issuedAt.Unix() / totpPeriod
+	code := generateHOTP(key, issuedStep)
+
+	// Первое использование кода
+	lastStep, ok := ValidateTOTP(testTOTPSecret, code, issuedAt)
+	if !ok || lastStep != issuedStep {
+		t.Fatalf("ValidateTOTP = (%d, %v), want (%d, true)", lastStep, ok, issuedStep)
+	}
+
+	// Повтор того же кода в следующем периоде попадает в допуск, но возвращает период самого кода,
+	// поэтому проверка step > lastStep отклоняет его
+	step, ok := ValidateTOTP(testTOTPSecret, code, issuedAt.Add(totpPeriod*time.Second))
+	if !ok {
+		t.Fatalf("ValidateTOTP rejected code within skew window")
+	}
+	if step != lastStep {
+		t.Errorf("ValidateTOTP step = %d, want last used step %d", step, lastStep)
+	}
+
+	// Код следующего периода имеет больший номер периода и принимается
+	nextStep, ok := ValidateTOTP(testTOTPSecret, generateHOTP(key, issuedStep+1), issuedAt.Add(totpPeriod*time.Second))
+	if !ok || nextStep <= lastStep {
+		t.Errorf("ValidateTOTP = (%d, %v), want step greater than %d", nextStep, ok, lastStep)
+	}
+}
+
+func TestValidateTOTPInvalidInput(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{name: "wrong code", secret: testTOTPSecret, code: "000000"},
+		{name: "short code", secret: testTOTPSecret, code: "08180"},
+		{name: "long code", secret: testTOTPSecret, code: "0818040"},
+		{name: "invalid secret", secret: "not base32!", code: "081804"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+				t.Errorf("ValidateTOTP accepted %q", tt.code)
+			}
+		})
+	}
+}